@@ -18,6 +18,9 @@ type Argument struct {
 	shadow reflect.Value
 
 	_default string
+
+	// the initial value before parse
+	initial reflect.Value
 }
 
 func NewArgument(tracer *trace.Tracer, value reflect.Value, typ reflect.StructField) (arg *Argument, err error) {
@@ -39,6 +42,10 @@ func NewArgument(tracer *trace.Tracer, value reflect.Value, typ reflect.StructFi
 		arg.Flag, err = NewFlag(tracer, shadow, typ)
 	}
 
+	// save the initial value of the field
+	arg.initial = reflect.New(value.Type()).Elem()
+	arg.initial.Set(value)
+
 	if v, ok := arg.Tag.Lookup(KEY_DEFAULT); ok {
 		// set default if defined as tag
 		arg._default = v
@@ -86,3 +93,27 @@ func (arg *Argument) Default() (_default string) {
 func (arg *Argument) IsZero() bool {
 	return arg.Value.IsZero()
 }
+
+// restore the field and the shadow to the initial value
+func (arg *Argument) Reset() (err error) {
+	if err = arg.Flag.Reset(); err == nil {
+		arg.Value.Set(arg.initial)
+	}
+	return
+}
+
+// save the current value as the initial value, and the shadow starts from
+// the current value when parse
+func (arg *Argument) Snapshot() (err error) {
+	arg.initial.Set(arg.Value)
+
+	switch {
+	case arg.Value.Kind() != reflect.Ptr:
+		arg.Flag.initial.Set(arg.Value)
+	case arg.Value.IsNil():
+		arg.Flag.initial.Set(reflect.Zero(arg.Flag.initial.Type()))
+	default:
+		arg.Flag.initial.Set(arg.Value.Elem())
+	}
+	return
+}
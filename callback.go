@@ -7,11 +7,14 @@ import (
 	"sync"
 )
 
+// the global callbacks registry is guarded by the read-write lock, and the
+// callback is always executed outside the lock, so the callback may parse or
+// register callback itself and the distinct StrOpt can Parse concurrently.
 var (
 	// the global callbacks, register explicit
 	callbacks_pool = map[string]Callback{}
-	// the global lock when register callback
-	callbacks_lock = sync.Mutex{}
+	// the global lock when register and lookup callback
+	callbacks_lock = sync.RWMutex{}
 )
 
 // pre-defined callback function
@@ -30,6 +33,7 @@ var (
 // the callback function called when field set.
 type Callback func(stropt *StrOpt, field Field) error
 
+// register the global callback, raise panic if the name is duplicated.
 func RegisterCallback(name string, callback Callback) {
 	callbacks_lock.Lock()
 	defer callbacks_lock.Unlock()
@@ -42,57 +46,136 @@ func RegisterCallback(name string, callback Callback) {
 	callbacks_pool[name] = callback
 }
 
-func CallCallback(name string, stropt *StrOpt, field Field) (err error) {
+// register or replace the global callback, return the previous one if exists.
+func OverrideCallback(name string, callback Callback) (prev Callback) {
 	callbacks_lock.Lock()
 	defer callbacks_lock.Unlock()
 
+	prev = callbacks_pool[name]
+	callbacks_pool[name] = callback
+	return
+}
+
+// remove the global callback, return the removed one if exists.
+func UnregisterCallback(name string) (prev Callback) {
+	callbacks_lock.Lock()
+	defer callbacks_lock.Unlock()
+
+	prev = callbacks_pool[name]
+	delete(callbacks_pool, name)
+	return
+}
+
+// register the callback scoped on the StrOpt and inherited by sub-commands,
+// raise panic if the name is duplicated.
+func (stropt *StrOpt) RegisterCallback(name string, callback Callback) {
+	if _, ok := stropt.callbacks[name]; ok {
+		// duplicated callback, raise panic
+		panic(fmt.Sprintf("duplicate callback: %v", name))
+	}
+
+	stropt.OverrideCallback(name, callback)
+}
+
+// register or replace the callback scoped on the StrOpt, return the previous
+// one if exists.
+func (stropt *StrOpt) OverrideCallback(name string, callback Callback) (prev Callback) {
+	if stropt.callbacks == nil {
+		stropt.callbacks = map[string]Callback{}
+	}
+
+	prev = stropt.callbacks[name]
+	stropt.callbacks[name] = callback
+	return
+}
+
+// remove the callback scoped on the StrOpt, return the removed one if exists.
+func (stropt *StrOpt) UnregisterCallback(name string) (prev Callback) {
+	prev = stropt.callbacks[name]
+	delete(stropt.callbacks, name)
+	return
+}
+
+// add the callbacks triggered after the whole command-line consumed, which
+// is resolved as the normal callback with the nil field.
+func (stropt *StrOpt) OnComplete(names ...string) {
+	stropt.complete_callbacks = append(stropt.complete_callbacks, names...)
+}
+
+// lookup the scoped callback from the StrOpt to the root command
+func (stropt *StrOpt) lookupCallback(name string) (callback Callback, ok bool) {
+	for parser := stropt; parser != nil; parser = parser.parent {
+		if callback, ok = parser.callbacks[name]; ok {
+			return
+		}
+	}
+	return
+}
+
+// lookup the global callback by name
+func lookupGlobalCallback(name string) (callback Callback, ok bool) {
+	callbacks_lock.RLock()
+	defer callbacks_lock.RUnlock()
+
+	callback, ok = callbacks_pool[name]
+	return
+}
+
+// call the callback by name, the callback is searched from the local method
+// of the *Struct, the scoped callbacks, the method of StrOpt and then the
+// global callbacks.
+func CallCallback(name string, stropt *StrOpt, field Field) (err error) {
 	if stropt == nil {
 		err = fmt.Errorf("should provides valid stropt: %v", stropt)
 		return
 	}
 
+	ctx := stropt.newCallbackContext("", field)
+	err = stropt.callCallback(name, ctx)
+	return
+}
+
+// call the callback by name with the callback context
+func (stropt *StrOpt) callCallback(name string, ctx *CallbackContext) (err error) {
 	stropt.Debugf("try call callback %#v", name)
 
 	// call local callback if exists
-	callback_value := stropt.Value.MethodByName(name)
+	callback_value := reflect.ValueOf(stropt.instance()).MethodByName(name)
 	if callback_value.IsValid() && !callback_value.IsZero() {
-		// since the method is not Callback, need to convert type to function ptr
-		callback, ok := callback_value.Interface().(func(stropt *StrOpt, field Field) error)
-		if ok {
-			stropt.Infof("call local callback: %v", name)
-			// found the callback, call it
-			if err = callback(stropt, field); err != ERR_CALLBACK_NOT_IMPLEMENTED {
-				// only return when callback implemented
-				return
-			}
+		stropt.Infof("call local callback: %v", name)
+		if ok, err := callCallbackFunc(callback_value, ctx); ok && err != ERR_CALLBACK_NOT_IMPLEMENTED {
+			// only return when callback implemented
+			return err
 		}
 	}
 
-	// call global callback if exists
-	value := reflect.ValueOf(stropt)
-	callback_value = value.MethodByName(name)
+	// call scoped callback if exists
+	if callback, ok := stropt.lookupCallback(name); ok {
+		stropt.Infof("call scoped callback: %v", name)
+		if err = callback(stropt, ctx.Field); err != ERR_CALLBACK_NOT_IMPLEMENTED {
+			// only return when callback implemented
+			return
+		}
+	}
 
+	// call the method of StrOpt if exists
+	callback_value = reflect.ValueOf(stropt).MethodByName(name)
 	if callback_value.IsValid() && !callback_value.IsZero() {
-		// since the method is not Callback, need to convert type to function ptr
-		callback, ok := callback_value.Interface().(func(stropt *StrOpt, field Field) error)
-		if ok {
-			stropt.Infof("call local callback: %v", name)
-			// found the callback, call it
-			if err = callback(stropt, field); err != ERR_CALLBACK_NOT_IMPLEMENTED {
-				// only return when callback implemented
-				return
-			}
+		stropt.Infof("call StrOpt callback: %v", name)
+		if ok, err := callCallbackFunc(callback_value, ctx); ok && err != ERR_CALLBACK_NOT_IMPLEMENTED {
+			// only return when callback implemented
+			return err
 		}
 	}
 
 	// call global callback if exists
-	callback, ok := callbacks_pool[name]
+	callback, ok := lookupGlobalCallback(name)
 	if !ok {
 		err = fmt.Errorf("callback not found: %v", name)
 		return
 	}
 
 	stropt.Infof("call global callback: %v", name)
-	err = callback(stropt, field)
+	err = callback(stropt, ctx.Field)
 	return
 }
@@ -0,0 +1,125 @@
+package stropt
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// the context passed to the callback, which exposes the parsed field
+type CallbackContext struct {
+	// the parser which the field belongs to
+	Parser *StrOpt
+	// the field triggers the callback
+	Field Field
+	// the raw token, the option or the argument passed in command-line
+	Token string
+	// the arguments consumed by the field
+	Args []string
+	// the parsed value of the field
+	Value interface{}
+	// the path from the root command to the field
+	Path []string
+}
+
+// create the callback context for the field
+func (stropt *StrOpt) newCallbackContext(token string, field Field, args ...string) (ctx *CallbackContext) {
+	ctx = &CallbackContext{
+		Parser: stropt,
+		Field:  field,
+		Token:  token,
+		Args:   args,
+		Path:   stropt.path(),
+	}
+
+	if field != nil {
+		ctx.Path = append(ctx.Path, field.GetName())
+
+		if value := fieldValue(field); value.IsValid() && value.CanInterface() {
+			// the parsed value of the field
+			ctx.Value = value.Interface()
+		}
+	}
+	return
+}
+
+// the value of the field, which is the shadow for the sub-command
+func fieldValue(field Field) (value reflect.Value) {
+	switch field := field.(type) {
+	case *Argument:
+		value = field.Value
+	case *Flag:
+		value = field.Value
+	case *Flip:
+		value = field.Value
+	case *StrOpt:
+		value = field.Value
+		if field.shadow.IsValid() {
+			value = field.shadow
+		}
+	}
+	return
+}
+
+var (
+	type_error           = reflect.TypeOf((*error)(nil)).Elem()
+	type_callback_method = reflect.TypeOf((func(*StrOpt, Field) error)(nil))
+	type_callback_ctx    = reflect.TypeOf((*CallbackContext)(nil))
+)
+
+// call the callback function resolved via reflection, which may be one of
+//
+//	func(stropt *StrOpt, field Field) error
+//	func(ctx *CallbackContext) error
+//	func(value T) error
+//
+// and return false if the function is not the supported callback.
+func callCallbackFunc(fn reflect.Value, ctx *CallbackContext) (ok bool, err error) {
+	typ := fn.Type()
+
+	switch {
+	case typ == type_callback_method:
+		ok = true
+		err = fn.Interface().(func(*StrOpt, Field) error)(ctx.Parser, ctx.Field)
+		return
+	case typ.NumIn() != 1 || typ.NumOut() != 1 || typ.Out(0) != type_error:
+		// not the supported callback
+		return
+	case typ.In(0) == type_callback_ctx:
+		ok = true
+		err = callError(fn.Call([]reflect.Value{reflect.ValueOf(ctx)}))
+		return
+	}
+
+	// the typed callback, pass the parsed value
+	value := reflect.ValueOf(ctx.Value)
+	switch in := typ.In(0); {
+	case !value.IsValid():
+		ok = true
+		err = fmt.Errorf("callback cannot accept the empty value as %v", in)
+	case value.Type().AssignableTo(in):
+		ok = true
+		err = callError(fn.Call([]reflect.Value{value}))
+	case value.Kind() == reflect.Ptr && value.Type().Elem().AssignableTo(in):
+		ok = true
+		switch value.IsNil() {
+		case true:
+			// pass the zero value of the nil pointer
+			err = callError(fn.Call([]reflect.Value{reflect.Zero(in)}))
+		case false:
+			err = callError(fn.Call([]reflect.Value{value.Elem()}))
+		}
+	default:
+		ok = true
+		err = fmt.Errorf("callback cannot accept %v as %v", value.Type(), in)
+	}
+
+	return
+}
+
+// the error returned from the reflect.Value.Call
+func callError(out []reflect.Value) (err error) {
+	if len(out) > 0 && !out[0].IsNil() {
+		err = out[0].Interface().(error)
+	}
+	return
+}
@@ -0,0 +1,205 @@
+package stropt
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type Nested struct {
+	Name string `desc:"the name" callback:"Nested_"`
+
+	inner *Foo
+}
+
+// parse another StrOpt inside the callback
+func (nested *Nested) Nested_(stropt *StrOpt, _field Field) (err error) {
+	nested.inner = &Foo{}
+	_, err = MustNew(nested.inner).Parse("--name", nested.Name, "--level", "info")
+	return
+}
+
+func TestNestedCallback(t *testing.T) {
+	nested := &Nested{}
+	parser := MustNew(nested)
+
+	if _, err := parser.Parse("--name", "inner"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if nested.inner == nil || nested.inner.Name != "inner" {
+		t.Errorf("expect parse inside callback: %#v", nested.inner)
+	}
+}
+
+func TestConcurrentParse(t *testing.T) {
+	wg := sync.WaitGroup{}
+
+	for idx := 0; idx < 16; idx++ {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			nested := &Nested{}
+			parser := MustNew(nested)
+			parser.NoExit(true)
+
+			name := fmt.Sprintf("name-%v", idx)
+			if _, err := parser.Parse("--name", name); err != nil {
+				t.Errorf("cannot parse: %v", err)
+			} else if nested.inner.Name != name {
+				t.Errorf("expect %v: %v", name, nested.inner.Name)
+			}
+
+			foo := &Foo{}
+			parser = MustNew(foo)
+			parser.NoExit(true)
+			if _, err := parser.Parse("-v"); err == nil {
+				t.Errorf("expect version in no-exit mode")
+			}
+		}(idx)
+	}
+
+	wg.Wait()
+}
+
+func TestScopedCallback(t *testing.T) {
+	called := ""
+	debug := func(name string) Callback {
+		return func(stropt *StrOpt, field Field) (err error) {
+			called = name
+			return
+		}
+	}
+
+	type Debug struct {
+		Debug bool `callback:"Debug_"`
+	}
+
+	type Scoped struct {
+		Debug bool `callback:"Debug_"`
+
+		Sub *Debug `name:"sub"`
+	}
+
+	RegisterCallback("Debug_", debug("global"))
+	defer UnregisterCallback("Debug_")
+
+	scoped := &Scoped{}
+	parser := MustNew(scoped)
+	other := MustNew(&Debug{})
+
+	parser.RegisterCallback("Debug_", debug("scoped"))
+	if _, err := parser.Parse("--debug"); err != nil || called != "scoped" {
+		t.Errorf("expect call scoped callback (%v): %v", err, called)
+	} else if _, err := parser.Parse("sub", "--debug"); err != nil || called != "scoped" {
+		t.Errorf("expect sub-command inherit scoped callback (%v): %v", err, called)
+	} else if _, err := other.Parse("--debug"); err != nil || called != "global" {
+		t.Errorf("expect fallback to global callback (%v): %v", err, called)
+	}
+
+	OverrideCallback("Debug_", debug("override"))
+	parser.UnregisterCallback("Debug_")
+	if _, err := parser.Parse("--debug"); err != nil || called != "override" {
+		t.Errorf("expect call overrided global callback (%v): %v", err, called)
+	}
+}
+
+type Typed struct {
+	Age  int     `callback:"Age_"`
+	Name *string `callback:"Name_"`
+	Flip bool    `shortcut:"f" callback:"Flip_"`
+
+	age  int
+	name string
+	ctx  *CallbackContext
+}
+
+func (typed *Typed) Age_(age int) (err error) {
+	typed.age = age
+	return
+}
+
+func (typed *Typed) Name_(name string) (err error) {
+	typed.name = name
+	return
+}
+
+func (typed *Typed) Flip_(ctx *CallbackContext) (err error) {
+	typed.ctx = ctx
+	return
+}
+
+func TestTypedCallback(t *testing.T) {
+	typed := &Typed{}
+	parser := MustNew(typed)
+
+	if _, err := parser.Parse("--age", "12", "-f", "foo"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	}
+
+	switch {
+	case typed.age != 12:
+		t.Errorf("expect typed callback get 12: %v", typed.age)
+	case typed.name != "foo":
+		t.Errorf("expect typed callback get foo: %v", typed.name)
+	case typed.ctx == nil:
+		t.Fatalf("expect callback context")
+	case typed.ctx.Token != "-f" || typed.ctx.Value != true || typed.ctx.Parser != parser:
+		t.Errorf("invalid callback context: %#v", typed.ctx)
+	case fmt.Sprintf("%v", typed.ctx.Path) != "[typed flip]":
+		t.Errorf("invalid callback path: %v", typed.ctx.Path)
+	}
+}
+
+type SelectSub struct {
+	Name string `callback:"Name_"`
+}
+
+type Select struct {
+	Flip bool
+
+	*SelectSub `name:"sub" callback:"Sub_"`
+
+	trace []string
+}
+
+func (sel *Select) Sub_(ctx *CallbackContext) (err error) {
+	sub := ctx.Value.(*SelectSub)
+	sel.trace = append(sel.trace, fmt.Sprintf("%v:%v", ctx.Token, sub.Name))
+	return
+}
+
+func (sel *Select) Complete_(stropt *StrOpt, field Field) (err error) {
+	sel.trace = append(sel.trace, fmt.Sprintf("complete:%v", stropt.GetName()))
+	return
+}
+
+func TestSelectCallback(t *testing.T) {
+	sel := &Select{}
+	parser := MustNew(sel)
+	parser.OnComplete("Complete_")
+	parser.OverrideCallback("Name_", func(stropt *StrOpt, field Field) (err error) {
+		sel.trace = append(sel.trace, fmt.Sprintf("name:%v", field.(*Flag).Value))
+		return
+	})
+
+	if _, err := parser.Parse("--flip"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if fmt.Sprintf("%v", sel.trace) != "[complete:select]" {
+		t.Errorf("expect complete callback: %v", sel.trace)
+	}
+
+	sel.trace = nil
+	if _, err := parser.Parse("sub", "--name", "foo"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if fmt.Sprintf("%v", sel.trace) != "[sub: name:foo complete:select]" {
+		t.Errorf("expect sub-command callback before the sub-command parse: %v", sel.trace)
+	}
+
+	sel.trace = nil
+	if _, err := parser.Parse("sub", "--unknown"); err == nil {
+		t.Fatalf("expect the unknown option of the sub-command")
+	} else if fmt.Sprintf("%v", sel.trace) != "[sub:]" {
+		t.Errorf("expect sub-command callback even parse fail: %v", sel.trace)
+	}
+}
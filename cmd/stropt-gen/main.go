@@ -0,0 +1,369 @@
+// the generator of the *Struct from the JSON schema exported by StrOpt.Schema
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cmj0121/stropt"
+)
+
+// the command-line options of stropt-gen
+type Gen struct {
+	stropt.Help
+
+	Package string `shortcut:"p" default:"main" desc:"the package name of the generated file"`
+	Type    string `shortcut:"t" desc:"the type name of the root command, default by the schema name"`
+	Output  string `shortcut:"o" desc:"write into the file instead of the standard output"`
+
+	Schema *string `desc:"the JSON schema file, read from the standard input when not set"`
+}
+
+// read the schema, generate the Go file and write to the output
+func (gen *Gen) Run(ctx context.Context) (err error) {
+	var data []byte
+	switch gen.Schema {
+	case nil:
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(*gen.Schema)
+	}
+	if err != nil {
+		err = fmt.Errorf("cannot read schema: %w", err)
+		return
+	}
+
+	var schema stropt.Schema
+	if err = json.Unmarshal(data, &schema); err != nil {
+		err = fmt.Errorf("cannot decode schema: %w", err)
+		return
+	}
+
+	var src []byte
+	if src, err = Generate(schema, gen.Package, gen.Type); err != nil {
+		return
+	}
+
+	switch gen.Output {
+	case "":
+		_, err = os.Stdout.Write(src)
+	default:
+		err = os.WriteFile(gen.Output, src, 0644)
+	}
+	return
+}
+
+// the known packages used in the field type
+var packages = map[string]string{
+	"big":  "math/big",
+	"net":  "net",
+	"os":   "os",
+	"time": "time",
+}
+
+// the package qualifier in the field type, like time.Duration
+var re_qualifier = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// the generator of the *Struct
+type generator struct {
+	// the generated type declarations, by the order
+	types []string
+	// the imported packages
+	imports map[string]bool
+}
+
+// generate the formatted Go file from the schema, the type name of the root
+// command is the camel-case schema name when not set.
+func Generate(schema stropt.Schema, pkg, typ string) (src []byte, err error) {
+	if typ == "" {
+		typ = camel(schema.Name)
+	}
+
+	gen := &generator{imports: map[string]bool{}}
+	if err = gen.command(typ, schema); err != nil {
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "// Code generated by stropt-gen. DO NOT EDIT.\n\npackage %v\n", pkg)
+
+	if len(gen.imports) > 0 {
+		var imports []string
+		for path := range gen.imports {
+			imports = append(imports, strconv.Quote(path))
+		}
+		sort.Strings(imports)
+
+		fmt.Fprintf(buff, "\nimport (\n\t%v\n)\n", strings.Join(imports, "\n\t"))
+	}
+
+	for _, decl := range gen.types {
+		fmt.Fprintf(buff, "\n%v", decl)
+	}
+
+	if src, err = format.Source(buff.Bytes()); err != nil {
+		err = fmt.Errorf("cannot format the generated file: %w", err)
+		return
+	}
+	return
+}
+
+// generate the struct of the command and its sub-commands
+func (gen *generator) command(typ string, schema stropt.Schema) (err error) {
+	if !isIdent(typ) {
+		err = fmt.Errorf("invalid type name of command %#v: %#v", schema.Name, typ)
+		return
+	}
+
+	buff := &bytes.Buffer{}
+	if schema.Desc != "" {
+		fmt.Fprintf(buff, "// %v\n", strings.ReplaceAll(schema.Desc, "\n", "\n// "))
+	}
+	fmt.Fprintf(buff, "type %v struct {\n", typ)
+
+	idx := len(gen.types)
+	gen.types = append(gen.types, "")
+
+	for _, fields := range [][]stropt.SchemaField{schema.Options, schema.Arguments} {
+		for _, field := range fields {
+			var line string
+			if line, err = gen.field(field); err != nil {
+				err = fmt.Errorf("%v: %w", schema.Name, err)
+				return
+			}
+			buff.WriteString(line)
+		}
+	}
+
+	for _, sub := range schema.Commands {
+		name := camel(sub.Name)
+		sub_typ := typ + name
+		if err = gen.command(sub_typ, sub); err != nil {
+			return
+		}
+
+		attrs := []string{}
+		if sub.Hidden {
+			attrs = append(attrs, stropt.KEY_ATTR_HIDDEN)
+		}
+
+		var order string
+		if sub.Order != 0 {
+			order = strconv.Itoa(sub.Order)
+		}
+
+		tags := []tagPair{
+			pair(stropt.KEY_NAME, rename(name, sub.Name)),
+			pair(stropt.KEY_ALIAS, strings.Join(sub.Aliases, " ")),
+			pair(stropt.KEY_ATTR, strings.Join(attrs, " ")),
+			pair(stropt.KEY_CALLBACK, sub.Callback),
+			optional(stropt.KEY_DEPRECATED, sub.Deprecated),
+			pair(stropt.KEY_ORDER, order),
+			pair(stropt.KEY_CATEGORY, sub.Category),
+			pair(stropt.KEY_EXAMPLE, sub.Example),
+			pair(stropt.KEY_DESC, sub.Desc),
+		}
+
+		var tag string
+		if tag, err = structTag(tags); err != nil {
+			err = fmt.Errorf("%v: %w", sub.Name, err)
+			return
+		}
+		fmt.Fprintf(buff, "\t%v *%v %v\n", name, sub_typ, tag)
+	}
+
+	buff.WriteString("}\n")
+	gen.types[idx] = buff.String()
+	return
+}
+
+// generate the single field line of the option or argument
+func (gen *generator) field(field stropt.SchemaField) (line string, err error) {
+	name := camel(field.Name)
+	if !isIdent(name) {
+		err = fmt.Errorf("invalid field name: %#v", field.Name)
+		return
+	}
+
+	attrs := []string{}
+	typ := field.Type
+
+	switch field.Kind {
+	case stropt.SCHEMA_KIND_FLIP:
+		if typ == "" {
+			typ = "bool"
+		} else if typ != "bool" {
+			err = fmt.Errorf("flip %v should be bool: %v", field.Name, typ)
+			return
+		}
+	case stropt.SCHEMA_KIND_FLAG:
+		switch {
+		case typ == "":
+			typ = "string"
+		case strings.HasPrefix(typ, "[]"):
+			err = fmt.Errorf("flag %v cannot be slice: %v", field.Name, typ)
+			return
+		case strings.HasPrefix(typ, "*"):
+			// the pointer is the argument by default
+			attrs = append(attrs, stropt.KEY_ATTR_FLAG)
+		}
+	case stropt.SCHEMA_KIND_ARGUMENT:
+		switch {
+		case typ == "":
+			typ = "*string"
+		case !strings.HasPrefix(typ, "*") && !strings.HasPrefix(typ, "[]"):
+			err = fmt.Errorf("argument %v should be pointer or slice: %v", field.Name, typ)
+			return
+		}
+	default:
+		err = fmt.Errorf("unknown kind of %v: %#v", field.Name, field.Kind)
+		return
+	}
+
+	for _, match := range re_qualifier.FindAllStringSubmatch(typ, -1) {
+		path, ok := packages[match[1]]
+		if !ok {
+			err = fmt.Errorf("unsupported type of %v: %v", field.Name, typ)
+			return
+		}
+		gen.imports[path] = true
+	}
+
+	if field.Required {
+		attrs = append(attrs, stropt.KEY_ATTR_REQUIRED)
+	}
+	if field.Hidden {
+		attrs = append(attrs, stropt.KEY_ATTR_HIDDEN)
+	}
+	if field.Persistent {
+		attrs = append(attrs, stropt.KEY_ATTR_PERSISTENT)
+	}
+	if field.Secret {
+		attrs = append(attrs, stropt.KEY_ATTR_SECRET)
+	}
+
+	_default := field.Default
+	if field.Secret {
+		// the default of the secret is masked in the schema, never generate
+		_default = ""
+	}
+
+	tags := []tagPair{
+		pair(stropt.KEY_NAME, rename(name, field.Name)),
+		pair(stropt.KEY_SHORTCUT, field.Shortcut),
+		pair(stropt.KEY_ALIAS, strings.Join(field.Aliases, " ")),
+		pair(stropt.KEY_CHOICE, strings.Join(field.Choices, " ")),
+		pair(stropt.KEY_DEFAULT, _default),
+		pair(stropt.KEY_ATTR, strings.Join(attrs, " ")),
+		pair(stropt.KEY_CALLBACK, field.Callback),
+		optional(stropt.KEY_DEPRECATED, field.Deprecated),
+		pair(stropt.KEY_FORWARD, field.Forward),
+		pair(stropt.KEY_DESC, field.Desc),
+	}
+
+	var tag string
+	if tag, err = structTag(tags); err != nil {
+		err = fmt.Errorf("%v: %w", field.Name, err)
+		return
+	}
+
+	line = fmt.Sprintf("\t%v %v %v\n", name, typ, tag)
+	return
+}
+
+// the name tag is only necessary when the lowercase field name is not the name
+func rename(field, name string) (tag string) {
+	if strings.ToLower(field) != name {
+		tag = name
+	}
+	return
+}
+
+// the key-value pair of the struct tag, skipped when not set
+type tagPair struct {
+	key   string
+	value string
+	set   bool
+}
+
+// the key-value pair which is set only when the value is not empty
+func pair(key, value string) tagPair {
+	return tagPair{key: key, value: value, set: value != ""}
+}
+
+// the key-value pair which is set when the value exists, even it is empty
+func optional(key string, value *string) (tag tagPair) {
+	tag.key = key
+	if value != nil {
+		tag.value, tag.set = *value, true
+	}
+	return
+}
+
+// build the raw struct tag from the key-value pairs, skip the pair not set
+func structTag(tags []tagPair) (tag string, err error) {
+	var pairs []string
+	for _, kv := range tags {
+		if !kv.set {
+			continue
+		}
+
+		value := strconv.Quote(kv.value)
+		if strings.Contains(value, "`") {
+			err = fmt.Errorf("cannot contain backquote in %v: %v", kv.key, kv.value)
+			return
+		}
+		pairs = append(pairs, fmt.Sprintf("%v:%v", kv.key, value))
+	}
+
+	if len(pairs) > 0 {
+		tag = "`" + strings.Join(pairs, " ") + "`"
+	}
+	return
+}
+
+// convert the kebab-case or snake-case name to the exported camel-case
+func camel(name string) (ident string) {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		ident += string(runes)
+	}
+	return
+}
+
+// check the name is the valid exported identifier
+func isIdent(name string) (ok bool) {
+	for idx, r := range name {
+		switch {
+		case idx == 0 && !unicode.IsUpper(r):
+			return
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_':
+			return
+		}
+	}
+
+	ok = name != ""
+	return
+}
+
+func main() {
+	gen := Gen{}
+	parser := stropt.MustNew(&gen)
+	parser.Name("stropt-gen")
+	parser.Run()
+}
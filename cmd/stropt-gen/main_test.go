@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cmj0121/stropt"
+)
+
+type Deploy struct {
+	Force   bool          `shortcut:"f" desc:"force deploy"`
+	Timeout time.Duration `default:"30s" desc:"the timeout"`
+	Target  *string       `attr:"required" desc:"the target"`
+}
+
+type App struct {
+	stropt.Help
+
+	LogLevel string `name:"log-level" choice:"info debug" attr:"persistent" desc:"the log level"`
+
+	*Deploy `desc:"deploy the \"app\"" alias:"up" category:"Operation"`
+}
+
+func TestGenerate(t *testing.T) {
+	app := stropt.MustNew(&App{})
+	app.Name("app")
+
+	src, err := Generate(app.Schema(), "cli", "")
+	if err != nil {
+		t.Fatalf("cannot generate: %v", err)
+	} else if _, err := parser.ParseFile(token.NewFileSet(), "app.go", src, 0); err != nil {
+		t.Fatalf("invalid generated file: %v\n%s", err, src)
+	}
+
+	for _, expect := range []string{
+		"package cli",
+		"\t\"time\"",
+		"type App struct {",
+		"\tHelp     bool       `shortcut:\"h\" callback:\"Help_\" desc:\"show this help message and exit\"`",
+		"\tLogLevel string     `name:\"log-level\" choice:\"info debug\" attr:\"persistent\" desc:\"the log level\"`",
+		"\tDeploy   *AppDeploy `alias:\"up\" category:\"Operation\" desc:\"deploy the \\\"app\\\"\"`",
+		"type AppDeploy struct {",
+		"\tTimeout time.Duration `default:\"30s\" desc:\"the timeout\"`",
+		"\tTarget  *string       `attr:\"required\" desc:\"the target\"`",
+	} {
+		if !strings.Contains(string(src), expect) {
+			t.Errorf("expect %q in the generated file:\n%s", expect, src)
+		}
+	}
+
+	schema := stropt.Schema{Name: "bad", Options: []stropt.SchemaField{{Name: "x", Kind: "flag", Type: "[]string"}}}
+	if _, err := Generate(schema, "main", ""); err == nil {
+		t.Errorf("expect cannot generate the slice flag")
+	}
+}
+
+type Legacy struct {
+	Level string        `choice:"info debug" desc:"the log level"`
+	Quiet bool          `deprecated:""`
+	Mode  string        `deprecated:"use --level instead" forward:"level"`
+	Wait  time.Duration `default:"1s"`
+	Files []string
+	Key   string `attr:"secret" default:"changeme"`
+
+	Build *struct {
+		Fast bool `desc:"fast build"`
+	} `order:"2" desc:"build the app"`
+	Clean *struct {
+		Target *string
+	} `order:"1" deprecated:"" category:"Maintenance"`
+}
+
+func TestGenerateRoundTrip(t *testing.T) {
+	legacy := stropt.MustNew(&Legacy{})
+	legacy.Name("legacy")
+	expect := legacy.Schema()
+
+	src, err := Generate(expect, "main", "")
+	if err != nil {
+		t.Fatalf("cannot generate: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "legacy.go", src, 0)
+	if err != nil {
+		t.Fatalf("invalid generated file: %v\n%s", err, src)
+	}
+
+	typ, err := rebuild(file, "Legacy")
+	if err != nil {
+		t.Fatalf("cannot rebuild the generated type: %v\n%s", err, src)
+	}
+
+	parser, err := stropt.New(reflect.New(typ).Interface())
+	if err != nil {
+		t.Fatalf("cannot create from the generated type: %v\n%s", err, src)
+	}
+	parser.Name("legacy")
+
+	// the masked default of the secret is not generated
+	expect.Options[4].Default = ""
+	if strings.Contains(string(src), stropt.SECRET_MASK) {
+		t.Errorf("expect not generate the masked default:\n%s", src)
+	}
+
+	if schema := parser.Schema(); !reflect.DeepEqual(schema, expect) {
+		t.Errorf("expect the same schema:\n%#v\nbut got:\n%#v\n%s", expect, schema, src)
+	}
+}
+
+// the types used in the generated file of the round-trip test
+var builtins = map[string]reflect.Type{
+	"bool":          reflect.TypeOf(false),
+	"string":        reflect.TypeOf(""),
+	"time.Duration": reflect.TypeOf(time.Duration(0)),
+}
+
+// rebuild the struct type declared in the generated file by reflect
+func rebuild(file *ast.File, name string) (typ reflect.Type, err error) {
+	obj := file.Scope.Lookup(name)
+	if obj == nil {
+		err = fmt.Errorf("type %v not found", name)
+		return
+	}
+
+	spec, ok := obj.Decl.(*ast.TypeSpec).Type.(*ast.StructType)
+	if !ok {
+		err = fmt.Errorf("type %v is not struct", name)
+		return
+	}
+
+	var fields []reflect.StructField
+	for _, field := range spec.Fields.List {
+		var field_typ reflect.Type
+		if field_typ, err = rebuildType(file, field.Type); err != nil {
+			return
+		}
+
+		var tag string
+		if field.Tag != nil {
+			tag, _ = strconv.Unquote(field.Tag.Value)
+		}
+
+		fields = append(fields, reflect.StructField{
+			Name: field.Names[0].Name,
+			Type: field_typ,
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+
+	typ = reflect.StructOf(fields)
+	return
+}
+
+// rebuild the type expression of the field
+func rebuildType(file *ast.File, expr ast.Expr) (typ reflect.Type, err error) {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		if typ, err = rebuildType(file, expr.X); err == nil {
+			typ = reflect.PtrTo(typ)
+		}
+	case *ast.ArrayType:
+		if typ, err = rebuildType(file, expr.Elt); err == nil {
+			typ = reflect.SliceOf(typ)
+		}
+	case *ast.SelectorExpr:
+		typ = builtins[fmt.Sprintf("%v.%v", expr.X, expr.Sel)]
+	case *ast.Ident:
+		if typ = builtins[expr.Name]; typ == nil {
+			typ, err = rebuild(file, expr.Name)
+		}
+	}
+
+	if typ == nil && err == nil {
+		err = fmt.Errorf("unsupported type: %#v", expr)
+	}
+	return
+}
@@ -23,11 +23,40 @@ var (
 	KEY_CHOICE = "choice"
 	// the default value
 	KEY_DEFAULT = "default"
+	// the alias names of the option or sub-command, split by space
+	KEY_ALIAS = "alias"
+	// the deprecated message of the option or sub-command
+	KEY_DEPRECATED = "deprecated"
+	// forward the value of the deprecated option to the named option
+	KEY_FORWARD = "forward"
+	// the order of the sub-command shown in usage, lower first
+	KEY_ORDER = "order"
+	// the category of the sub-command shown in usage
+	KEY_CATEGORY = "category"
+	// the example of the sub-command shown in usage
+	KEY_EXAMPLE = "example"
 
 	// the attribute of field
 	KEY_ATTR          = "attr"
 	KEY_ATTR_FLAG     = "flag"
 	KEY_ATTR_REQUIRED = "required"
+	KEY_ATTR_HIDDEN   = "hidden"
+	// the value is sensitive, masked when shown and read without echo when prompt
+	KEY_ATTR_SECRET = "secret"
+	// the option is resolvable in all the descendant sub-commands
+	KEY_ATTR_PERSISTENT = "persistent"
+)
+
+// pre-defined sub-command
+var (
+	// the built-in help command, show the help message of the sub-command path
+	CMD_HELP = "help"
+)
+
+// pre-defined hidden option
+var (
+	// the built-in option, show the schema of the command tree as JSON
+	OPT_SCHEMA_JSON = "schema-json"
 )
 
 // pre-defined tag used in stropt
@@ -35,3 +64,20 @@ var (
 	// the field should be ignored in stropt
 	TAG_IGNORE = "-"
 )
+
+// the mask shown instead of the value of the secret field
+var (
+	SECRET_MASK = "******"
+)
+
+// pre-defined exit code
+var (
+	// the command run successfully
+	EXIT_SUCCESS = 0
+	// the general failure
+	EXIT_FAILURE = 1
+	// the invalid command-line usage
+	EXIT_USAGE = 2
+	// the command is interrupted by SIGINT
+	EXIT_INTERRUPT = 130
+)
@@ -0,0 +1,95 @@
+package stropt
+
+import (
+	"errors"
+)
+
+// the sentinel error returned from the built-in callback in no-exit mode
+var (
+	// the help message is requested
+	ERR_HELP = errors.New("help requested")
+	// the version info is requested
+	ERR_VERSION = errors.New("version requested")
+	// the schema of the command tree is requested
+	ERR_SCHEMA = errors.New("schema requested")
+)
+
+// the error which carries the rendered text, like the help message
+type TextError struct {
+	// the wrapped error
+	Err error
+	// the rendered text
+	Text string
+}
+
+// the error message of the wrapped error
+func (text_err *TextError) Error() (msg string) {
+	if text_err.Err != nil {
+		msg = text_err.Err.Error()
+	}
+	return
+}
+
+// the wrapped error
+func (text_err *TextError) Unwrap() (err error) {
+	err = text_err.Err
+	return
+}
+
+// the error which carries the exit code, honoured by StrOpt.Run
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// the error with the exit code
+type ExitError struct {
+	// the wrapped error
+	Err error
+	// the exit code
+	Code int
+}
+
+// wrap the error with the exit code
+func NewExitError(err error, code int) (exit_err *ExitError) {
+	exit_err = &ExitError{
+		Err:  err,
+		Code: code,
+	}
+	return
+}
+
+// the error message of the wrapped error
+func (exit_err *ExitError) Error() (msg string) {
+	if exit_err.Err != nil {
+		msg = exit_err.Err.Error()
+	}
+	return
+}
+
+// the wrapped error
+func (exit_err *ExitError) Unwrap() (err error) {
+	err = exit_err.Err
+	return
+}
+
+// the exit code carried by the error
+func (exit_err *ExitError) ExitCode() (code int) {
+	code = exit_err.Code
+	return
+}
+
+// get the exit code from the error chain, return the fallback code if
+// the error does not carry the exit code.
+func ExitCode(err error, fallback int) (code int) {
+	var coder ExitCoder
+
+	switch {
+	case err == nil:
+		code = EXIT_SUCCESS
+	case errors.As(err, &coder):
+		code = coder.ExitCode()
+	default:
+		code = fallback
+	}
+	return
+}
@@ -30,4 +30,9 @@ type Field interface {
 
 	// check the field set or not
 	IsZero() bool
+
+	// restore the field to the initial value
+	Reset() error
+	// save the current value as the initial value restored by Reset
+	Snapshot() error
 }
@@ -28,6 +28,9 @@ type Flag struct {
 
 	// the default value
 	_default string
+
+	// the initial value before parse
+	initial reflect.Value
 }
 
 func NewFlag(tracer *trace.Tracer, value reflect.Value, typ reflect.StructField) (flag *Flag, err error) {
@@ -35,7 +38,9 @@ func NewFlag(tracer *trace.Tracer, value reflect.Value, typ reflect.StructField)
 		Tracer:      tracer,
 		Value:       value,
 		StructField: typ,
+		initial:     reflect.New(value.Type()).Elem(),
 	}
+	flag.initial.Set(value)
 
 	if v, ok := flag.Tag.Lookup(KEY_DEFAULT); ok {
 		// set default if defined as tag
@@ -98,7 +103,7 @@ func (flag *Flag) Parse(args ...string) (n int, err error) {
 		}
 
 		if !found {
-			err = fmt.Errorf("should pass one of [%v]: %v", strings.Join(flag.choise, " "), args[0])
+			err = fmt.Errorf("should pass one of [%v]: %v", strings.Join(flag.choise, " "), flag.display(args[0]))
 			return
 		}
 	}
@@ -122,7 +127,7 @@ func (flag *Flag) Parse(args ...string) (n int, err error) {
 			return
 		}
 
-		err = fmt.Errorf("should pass %v: %v", flag.Hint(), args[0])
+		err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args[0]))
 		return
 	case os.File, *os.File:
 		var file *os.File
@@ -139,7 +144,7 @@ func (flag *Flag) Parse(args ...string) (n int, err error) {
 			return
 		}
 
-		err = fmt.Errorf("should pass %v: %v", flag.Hint(), args[0])
+		err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args[0]))
 		return
 	case net.IP, *net.IP:
 		var ip net.IP
@@ -157,7 +162,7 @@ func (flag *Flag) Parse(args ...string) (n int, err error) {
 			return
 		}
 
-		err = fmt.Errorf("should pass %v: %v", flag.Hint(), args[0])
+		err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args[0]))
 		return
 	case net.IPNet, *net.IPNet:
 		var inet *net.IPNet
@@ -170,7 +175,7 @@ func (flag *Flag) Parse(args ...string) (n int, err error) {
 			return
 		}
 
-		err = fmt.Errorf("should pass %v: %v", flag.Hint(), args[0])
+		err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args[0]))
 		return
 	case net.Interface, *net.Interface:
 		var iface *net.Interface
@@ -181,7 +186,7 @@ func (flag *Flag) Parse(args ...string) (n int, err error) {
 			return
 		}
 
-		err = fmt.Errorf("should pass %v: %v", flag.Hint(), args[0])
+		err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args[0]))
 		return
 	}
 
@@ -200,7 +205,7 @@ func (flag *Flag) parse(value reflect.Value, args string) (err error) {
 		var v int
 
 		if v, err = strconv.Atoi(args); err != nil {
-			err = fmt.Errorf("should pass %v: %v", flag.Hint(), args)
+			err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args))
 			return
 		}
 
@@ -209,10 +214,10 @@ func (flag *Flag) parse(value reflect.Value, args string) (err error) {
 		var v int
 
 		if v, err = strconv.Atoi(args); err != nil {
-			err = fmt.Errorf("should pass %v: %v", flag.Hint(), args)
+			err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args))
 			return
 		} else if v < 0 {
-			err = fmt.Errorf("should pass %v: %v", flag.Hint(), args)
+			err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args))
 			return
 		}
 
@@ -220,18 +225,18 @@ func (flag *Flag) parse(value reflect.Value, args string) (err error) {
 	case reflect.Float32, reflect.Float64:
 		rat := &big.Rat{}
 		if _, ok := rat.SetString(args); !ok {
-			err = fmt.Errorf("should pass %v: %v", flag.Hint(), args)
+			err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args))
 			return
 		}
 
 		float, exact := rat.Float64()
-		flag.Infof("convert %v to %v (exact: %v)", args, float, exact)
+		flag.Infof("convert %v to %v (exact: %v)", flag.display(args), flag.display(fmt.Sprint(float)), exact)
 		value.SetFloat(float)
 	case reflect.Complex64, reflect.Complex128:
 		var cplx complex128
 
 		if cplx, err = strconv.ParseComplex(args, 128); err != nil {
-			err = fmt.Errorf("should pass %v: %v", flag.Hint(), args)
+			err = fmt.Errorf("should pass %v: %v", flag.Hint(), flag.display(args))
 			return
 		}
 		value.SetComplex(cplx)
@@ -369,3 +374,29 @@ func (flag *Flag) Default() (_default string) {
 func (flag *Flag) IsZero() bool {
 	return flag.Value.IsZero()
 }
+
+// restore the field to the initial value
+func (flag *Flag) Reset() (err error) {
+	flag.Value.Set(flag.initial)
+	return
+}
+
+// save the current value as the initial value
+func (flag *Flag) Snapshot() (err error) {
+	flag.initial.Set(flag.Value)
+	return
+}
+
+// the raw value shown in the message and the trace, masked for the secret
+func (flag *Flag) display(value string) (text string) {
+	text = value
+	if attrs, ok := flag.Tag.Lookup(KEY_ATTR); ok {
+		for _, attr := range strings.Fields(attrs) {
+			if attr == KEY_ATTR_SECRET {
+				text = SECRET_MASK
+				break
+			}
+		}
+	}
+	return
+}
@@ -21,6 +21,9 @@ type Flip struct {
 
 	// the default value
 	_default string
+
+	// the initial value before parse
+	initial bool
 }
 
 func NewFlip(tracer *trace.Tracer, value reflect.Value, typ reflect.StructField) (flip *Flip, err error) {
@@ -33,6 +36,7 @@ func NewFlip(tracer *trace.Tracer, value reflect.Value, typ reflect.StructField)
 		Tracer:      tracer,
 		Value:       value,
 		StructField: typ,
+		initial:     value.Bool(),
 	}
 
 	if v, ok := flip.Tag.Lookup(KEY_DEFAULT); ok {
@@ -115,3 +119,15 @@ func (flip *Flip) Hint() (hint string) {
 func (flip *Flip) IsZero() bool {
 	return flip.Value.IsZero()
 }
+
+// restore the field to the initial value
+func (flip *Flip) Reset() (err error) {
+	flip.Value.SetBool(flip.initial)
+	return
+}
+
+// save the current value as the initial value
+func (flip *Flip) Snapshot() (err error) {
+	flip.initial = flip.Value.Bool()
+	return
+}
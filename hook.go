@@ -0,0 +1,185 @@
+package stropt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// the optional hook of the *Struct, called before the StrOpt parse the
+// arguments for the command.
+type BeforeParser interface {
+	BeforeParse(stropt *StrOpt) error
+}
+
+// the optional hook of the *Struct, called after the StrOpt parsed and
+// validated the arguments for the command.
+type AfterParser interface {
+	AfterParse(stropt *StrOpt) error
+}
+
+// the optional hook of the *Struct, called before run the selected command
+// and trigger from the root command to the selected one.
+type BeforeRunner interface {
+	BeforeRun(ctx context.Context) error
+}
+
+// the optional hook of the *Struct, called after the selected command run
+// successfully and trigger from the selected command back to the root.
+type AfterRunner interface {
+	AfterRun(ctx context.Context) error
+}
+
+// the command which can be executed, only the deepest selected command
+// would be run.
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// parse the command-line arguments and run the selected command, return
+// the error to the caller.
+func (stropt *StrOpt) Execute() (err error) {
+	err = stropt.ExecuteContext(context.Background())
+	return
+}
+
+// parse the command-line arguments and run the selected command with the
+// cancellable context, which is cancelled when receive SIGINT/SIGTERM and
+// force exit when receive the signal again.
+func (stropt *StrOpt) ExecuteContext(ctx context.Context) (err error) {
+	if _, err = stropt.Parse(os.Args[1:]...); err != nil {
+		// cannot parse the arguments
+		return
+	}
+
+	err = stropt.executeContext(ctx)
+	return
+}
+
+// run the selected command with the signal handlers
+func (stropt *StrOpt) executeContext(ctx context.Context) (err error) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	err = stropt.executeSignals(ctx, signals)
+	return
+}
+
+// run the selected command which is cancelled by the received signal, the
+// error carries the EXIT_INTERRUPT when cancelled by SIGINT.
+func (stropt *StrOpt) executeSignals(ctx context.Context, signals <-chan os.Signal) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// the signal cancels the command
+	interrupted := make(chan os.Signal, 1)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			stropt.Warnf("receive %v, cancel the command", sig)
+			interrupted <- sig
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			stropt.Warnf("receive %v again, force exit", sig)
+			stropt.exitWith(EXIT_INTERRUPT)
+		case <-done:
+		}
+	}()
+
+	if err = stropt.execute(ctx); err == nil || ctx.Err() == nil {
+		return
+	} else if !errors.Is(err, ctx.Err()) {
+		// the command is cancelled, keep the cancellation in the error chain
+		err = fmt.Errorf("%w: %v", ctx.Err(), err)
+	}
+
+	select {
+	case sig := <-interrupted:
+		if sig == syscall.SIGINT {
+			err = NewExitError(err, EXIT_INTERRUPT)
+		}
+	default:
+		// cancelled by the caller
+	}
+	return
+}
+
+// run the selected commands with the parents' hooks, the BeforeRun is
+// trigger from the root command and the AfterRun is trigger back to the root.
+func (stropt *StrOpt) execute(ctx context.Context) (err error) {
+	if hook, ok := stropt.instance().(BeforeRunner); ok {
+		stropt.Debugf("call BeforeRun on %v", stropt.name)
+		if err = hook.BeforeRun(ctx); err != nil {
+			return
+		}
+	}
+
+	switch runner, ok := stropt.instance().(Runner); {
+	case len(stropt.selected) > 0:
+		for _, sub := range stropt.selected {
+			// run the selected sub-commands by order
+			if err = sub.execute(ctx); err != nil {
+				return
+			}
+		}
+	case ok:
+		stropt.Infof("run command %v", stropt.name)
+		if err = runner.Run(ctx); err != nil {
+			return
+		}
+	default:
+		stropt.Infof("command %v not runnable, skip", stropt.name)
+	}
+
+	if hook, ok := stropt.instance().(AfterRunner); ok {
+		stropt.Debugf("call AfterRun on %v", stropt.name)
+		if err = hook.AfterRun(ctx); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// call the BeforeParse hook if the *Struct implemented
+func (stropt *StrOpt) beforeParse() (err error) {
+	if hook, ok := stropt.instance().(BeforeParser); ok {
+		stropt.Debugf("call BeforeParse on %v", stropt.name)
+		err = hook.BeforeParse(stropt)
+	}
+	return
+}
+
+// call the AfterParse hook if the *Struct implemented
+func (stropt *StrOpt) afterParse() (err error) {
+	if hook, ok := stropt.instance().(AfterParser); ok {
+		stropt.Debugf("call AfterParse on %v", stropt.name)
+		err = hook.AfterParse(stropt)
+	}
+	return
+}
+
+// the instance of *Struct served by the StrOpt, which is the shadow value
+// for the sub-command.
+func (stropt *StrOpt) instance() (in interface{}) {
+	switch {
+	case stropt.shadow.IsValid():
+		in = stropt.shadow.Interface()
+	default:
+		in = stropt.Value.Interface()
+	}
+	return
+}
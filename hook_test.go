@@ -0,0 +1,209 @@
+package stropt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type HookSub struct {
+	Name string `desc:"the name"`
+
+	trace *[]string
+}
+
+func (sub *HookSub) BeforeParse(stropt *StrOpt) (err error) {
+	*sub.trace = append(*sub.trace, "sub:before-parse")
+	return
+}
+
+func (sub *HookSub) AfterParse(stropt *StrOpt) (err error) {
+	*sub.trace = append(*sub.trace, "sub:after-parse")
+	return
+}
+
+func (sub *HookSub) Run(ctx context.Context) (err error) {
+	*sub.trace = append(*sub.trace, "sub:run:"+sub.Name)
+	return
+}
+
+type Hook struct {
+	Flip bool `desc:"store true/false field"`
+
+	*HookSub `name:"sub"`
+
+	trace []string
+}
+
+func (hook *Hook) BeforeParse(stropt *StrOpt) (err error) {
+	hook.trace = append(hook.trace, "root:before-parse")
+	return
+}
+
+func (hook *Hook) AfterParse(stropt *StrOpt) (err error) {
+	hook.trace = append(hook.trace, "root:after-parse")
+	return
+}
+
+func (hook *Hook) BeforeRun(ctx context.Context) (err error) {
+	hook.trace = append(hook.trace, "root:before-run")
+	return
+}
+
+func (hook *Hook) AfterRun(ctx context.Context) (err error) {
+	hook.trace = append(hook.trace, "root:after-run")
+	return
+}
+
+func (hook *Hook) Run(ctx context.Context) (err error) {
+	hook.trace = append(hook.trace, "root:run")
+	return
+}
+
+func TestExecuteHook(t *testing.T) {
+	hook := &Hook{}
+	parser := MustNew(hook)
+	// the sub-command's hook should share the same trace
+	parser.sub_fields["sub"].(*StrOpt).shadow.Interface().(*HookSub).trace = &hook.trace
+
+	if _, err := parser.Parse("--flip", "sub", "--name", "foo"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if err := parser.execute(context.Background()); err != nil {
+		t.Fatalf("cannot execute: %v", err)
+	}
+
+	expect := []string{
+		"root:before-parse",
+		"sub:before-parse",
+		"sub:after-parse",
+		"root:after-parse",
+		"root:before-run",
+		"sub:run:foo",
+		"root:after-run",
+	}
+	if strings.Join(hook.trace, " ") != strings.Join(expect, " ") {
+		t.Errorf("expect hooks %v: %v", expect, hook.trace)
+	}
+
+	hook.trace = nil
+	if _, err := parser.Parse("--flip"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if err := parser.execute(context.Background()); err != nil {
+		t.Fatalf("cannot execute: %v", err)
+	} else if hook.trace[len(hook.trace)-2] != "root:run" {
+		t.Errorf("expect run the root command: %v", hook.trace)
+	}
+}
+
+type Interrupt struct {
+	cancelled bool
+	signals   chan os.Signal
+}
+
+func (interrupt *Interrupt) Run(ctx context.Context) (err error) {
+	if interrupt.signals != nil {
+		interrupt.signals <- syscall.SIGINT
+	}
+
+	select {
+	case <-ctx.Done():
+		interrupt.cancelled = true
+		err = ctx.Err()
+	case <-time.After(time.Second):
+		err = fmt.Errorf("context not cancelled")
+	}
+	return
+}
+
+func TestExecuteInterrupt(t *testing.T) {
+	interrupt := &Interrupt{signals: make(chan os.Signal, 1)}
+	parser := MustNew(interrupt)
+
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	}
+
+	switch err := parser.executeSignals(context.Background(), interrupt.signals); {
+	case !errors.Is(err, context.Canceled):
+		t.Errorf("expect cancelled by SIGINT: %v", err)
+	case ExitCode(err, EXIT_FAILURE) != EXIT_INTERRUPT:
+		t.Errorf("expect exit code %v: %v", EXIT_INTERRUPT, ExitCode(err, EXIT_FAILURE))
+	case !interrupt.cancelled:
+		t.Errorf("expect run the command")
+	}
+
+	// cancelled by the caller without the signal
+	interrupt.signals = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	switch err := parser.executeSignals(ctx, make(chan os.Signal)); {
+	case !errors.Is(err, context.Canceled):
+		t.Errorf("expect cancelled by the caller: %v", err)
+	case ExitCode(err, EXIT_FAILURE) != EXIT_FAILURE:
+		t.Errorf("expect the general failure: %v", ExitCode(err, EXIT_FAILURE))
+	}
+}
+
+type Step struct {
+	Fast bool `desc:"run fast"`
+
+	name  string
+	trace *[]string
+}
+
+func (step *Step) Run(ctx context.Context) (err error) {
+	*step.trace = append(*step.trace, fmt.Sprintf("%v:%v", step.name, step.Fast))
+	return
+}
+
+type Pipeline struct {
+	Token string `attr:"required"`
+	Debug bool
+
+	Build  *Step
+	Test   *Step
+	Deploy *Step
+
+	trace []string
+}
+
+func TestChainSubCommand(t *testing.T) {
+	pipeline := &Pipeline{}
+	parser := MustNew(pipeline)
+	for _, name := range []string{"build", "test", "deploy"} {
+		step := parser.sub_fields[name].(*StrOpt).shadow.Interface().(*Step)
+		step.name = name
+		step.trace = &pipeline.trace
+	}
+
+	if _, err := parser.Parse("build", "--fast"); err == nil {
+		t.Errorf("expect the parent required check after sub-command")
+	} else if _, err := parser.Parse("--token", "x", "build", "--debug"); err == nil {
+		t.Errorf("expect non-persistent parent option not found in sub-command")
+	} else if _, err := parser.Parse("--token", "x", "build", "test"); err == nil {
+		t.Errorf("expect cannot chain sub-commands by default")
+	}
+
+	parser.Chain(true)
+	if n, err := parser.Parse("build", "--fast", "--debug", "--token", "x"); err != nil || n != 5 {
+		t.Errorf("expect continue parse parent options (%v): %v", n, err)
+	} else if !pipeline.Debug || pipeline.Token != "x" || !pipeline.Build.Fast {
+		t.Errorf("cannot parse parent options after sub-command: %#v", pipeline)
+	} else if _, err := parser.Parse("--token", "x", "build", "extra"); err == nil {
+		t.Errorf("expect reject the leftover argument: %v", err)
+	}
+
+	if _, err := parser.Parse("--token", "x", "build", "test", "--fast", "deploy"); err != nil {
+		t.Fatalf("cannot parse chained sub-commands: %v", err)
+	} else if err := parser.execute(context.Background()); err != nil {
+		t.Fatalf("cannot execute: %v", err)
+	} else if strings.Join(pipeline.trace, " ") != "build:false test:true deploy:false" {
+		t.Errorf("expect run the chained sub-commands: %v", pipeline.trace)
+	}
+}
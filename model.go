@@ -1,8 +1,8 @@
 package stropt
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 
 	"github.com/cmj0121/trace"
 )
@@ -47,8 +47,18 @@ func init() {
 
 // show the usage on stderr, and exit
 func help(stropt *StrOpt, _field Field) (err error) {
-	stropt.Usage(os.Stderr)
-	os.Exit(1)
+	if stropt.isNoExit() {
+		buff := &bytes.Buffer{}
+		stropt.Usage(buff)
+
+		err = &TextError{Err: ERR_HELP, Text: buff.String()}
+		return
+	}
+
+	stropt.Usage(stropt.getStderr())
+	stropt.exitWith(EXIT_FAILURE)
+	// stop the parse even the exit function returns
+	err = ERR_HELP
 	return
 }
 
@@ -59,13 +69,21 @@ var (
 
 // show the version info, may override by caller
 func version(stropt *StrOpt, _field Field) (err error) {
-	if ver == "" {
+	text := ver
+	if text == "" {
 		// show the StrOpt version info
-		ver = fmt.Sprintf("%v (v%d.%d.%d)", PROJ_NAME, MAJOR, MINOR, MAJOR)
+		text = fmt.Sprintf("%v (v%d.%d.%d)", PROJ_NAME, MAJOR, MINOR, MAJOR)
+	}
+
+	if stropt.isNoExit() {
+		err = &TextError{Err: ERR_VERSION, Text: text}
+		return
 	}
 
-	os.Stdout.WriteString(ver)
-	os.Exit(0)
+	stropt.getStdout().Write([]byte(text)) //nolint
+	stropt.exitWith(EXIT_SUCCESS)
+	// stop the parse even the exit function returns
+	err = ERR_VERSION
 	return
 }
 
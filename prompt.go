@@ -0,0 +1,159 @@
+package stropt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// enable the interactive mode, prompt the missing required option and
+// argument on the terminal instead of failure when parse.
+func (stropt *StrOpt) Interactive(interactive bool) {
+	stropt.interactive = interactive
+}
+
+// override the reader of the prompt answer, default is os.Stdin. The reader
+// which is not the file is always treated as the terminal.
+func (stropt *StrOpt) Stdin(r io.Reader) {
+	stropt.stdin = r
+}
+
+// check the interactive mode, inherit from the parent
+func (stropt *StrOpt) isInteractive() (interactive bool) {
+	interactive = stropt.interactive || (stropt.parent != nil && stropt.parent.isInteractive())
+	return
+}
+
+// the reader of the prompt answer, inherit from the parent
+func (stropt *StrOpt) getStdin() (r io.Reader) {
+	switch {
+	case stropt.stdin != nil:
+		r = stropt.stdin
+	case stropt.parent != nil:
+		r = stropt.parent.getStdin()
+	default:
+		r = os.Stdin
+	}
+	return
+}
+
+// check the missing value of the field can be prompted, only the flag and
+// the argument when the stdin is the terminal.
+func (stropt *StrOpt) canPrompt(field Field) (ok bool) {
+	if _, flip := field.(*Flip); flip || !stropt.isInteractive() {
+		return
+	}
+
+	switch r := stropt.getStdin().(type) {
+	case *os.File:
+		ok = isTerminal(r.Fd())
+	default:
+		ok = true
+	}
+	return
+}
+
+// prompt the value of the field until the answer is valid, the choice can be
+// answered by the number of the menu and the empty answer takes the default.
+func (stropt *StrOpt) prompt(field Field) (err error) {
+	w := stropt.getStderr()
+	r := stropt.getStdin()
+	secret := stropt.field_set_attr(field, KEY_ATTR_SECRET)
+	choices := field.GetChoice()
+	_default := field.Default()
+
+	if desc, ok := field.GetTag().Lookup(KEY_DESC); ok {
+		fmt.Fprintf(w, "%v: %v\n", field.GetName(), desc) //nolint
+	}
+	for idx, choice := range choices {
+		fmt.Fprintf(w, "  %d) %v\n", idx+1, choice) //nolint
+	}
+
+	question := strings.TrimRight(fmt.Sprintf("%v %v", field.GetName(), field.Hint()), " ")
+	if len(choices) > 0 {
+		question = fmt.Sprintf("%v [1-%d]", question, len(choices))
+	}
+	if _default != "" {
+		question = fmt.Sprintf("%v [default: %v]", question, stropt.defaultValue(field))
+	}
+
+	for {
+		w.Write([]byte(question + ": ")) //nolint
+
+		var answer string
+		if answer, err = stropt.readAnswer(r, secret); err != nil {
+			err = fmt.Errorf("option %#v is required but not set: %w", field.GetName(), err)
+			return
+		}
+
+		switch idx, e := strconv.Atoi(answer); {
+		case answer == "" && _default == "":
+			// ask again
+			continue
+		case answer == "":
+			answer = _default
+		case e == nil && idx >= 1 && idx <= len(choices):
+			answer = choices[idx-1]
+		}
+
+		if _, err = field.Parse(answer); err == nil {
+			stropt.Debugf("prompt %v set", field.GetName())
+			return
+		} else if secret {
+			// the error may contain the hidden answer
+			err = fmt.Errorf("invalid value of %v", field.GetName())
+		}
+		stropt.writeError(w, err)
+	}
+}
+
+// read the single line answer, the echo is disabled for the secret on the
+// terminal if the platform supports.
+func (stropt *StrOpt) readAnswer(r io.Reader, secret bool) (answer string, err error) {
+	if file, ok := r.(*os.File); ok && secret && isTerminal(file.Fd()) {
+		var restore func()
+		if restore, err = disableEcho(file.Fd()); err != nil {
+			stropt.Warnf("cannot disable echo: %v", err)
+		} else {
+			defer restore()
+			// the new line is not echoed
+			defer stropt.getStderr().Write([]byte("\n")) //nolint
+		}
+	}
+
+	if answer, err = readLine(r); err == nil && !secret {
+		answer = strings.TrimSpace(answer)
+	}
+	return
+}
+
+// read the line byte-by-byte, never consume more than the line from the reader
+func readLine(r io.Reader) (line string, err error) {
+	var buff []byte
+
+	b := make([]byte, 1)
+	for {
+		var n int
+		switch n, err = r.Read(b); {
+		case n > 0 && b[0] == '\n':
+			line = strings.TrimSuffix(string(buff), "\r")
+			err = nil
+			return
+		case n > 0:
+			buff = append(buff, b[0])
+		}
+
+		switch {
+		case errors.Is(err, io.EOF) && len(buff) > 0:
+			// the last line without the new line
+			line = string(buff)
+			err = nil
+			return
+		case err != nil:
+			return
+		}
+	}
+}
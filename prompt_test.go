@@ -0,0 +1,71 @@
+package stropt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Login struct {
+	Level    string  `choice:"info debug trace" attr:"required" desc:"the log level"`
+	Password string  `attr:"required secret" desc:"the password"`
+	Host     *string `attr:"required"`
+}
+
+func TestPrompt(t *testing.T) {
+	login := &Login{}
+	parser := MustNew(login)
+
+	if _, err := parser.Parse(); err == nil {
+		t.Fatalf("expect required check when not interactive")
+	}
+
+	stderr := &bytes.Buffer{}
+	parser.Interactive(true)
+	parser.Stderr(stderr)
+	parser.Stdin(strings.NewReader("warn\n2\n s3cret \n\n  example.com\n"))
+
+	switch _, err := parser.Parse(); {
+	case err != nil:
+		t.Fatalf("cannot parse with prompt: %v", err)
+	case login.Level != "debug" || login.Password != " s3cret " || login.Host == nil || *login.Host != "example.com":
+		t.Errorf("invalid prompt answer: %#v", login)
+	case !strings.Contains(stderr.String(), "level: the log level\n  1) info\n  2) debug\n  3) trace\nlevel STR [1-3]: "):
+		t.Errorf("expect prompt the menu: %q", stderr.String())
+	case !strings.Contains(stderr.String(), "error: should pass one of [info debug trace]: warn"):
+		t.Errorf("expect ask again when invalid: %q", stderr.String())
+	}
+
+	parser.Stdin(strings.NewReader("1\n"))
+	if _, err := parser.Parse(); err == nil || !strings.Contains(err.Error(), `option "password" is required but not set`) {
+		t.Errorf("expect required check when no more answer: %v", err)
+	}
+}
+
+type Unlock struct {
+	Delay time.Duration `attr:"required secret" default:"0s"`
+}
+
+func TestPromptSecret(t *testing.T) {
+	unlock := &Unlock{}
+	parser := MustNew(unlock)
+
+	stderr := &bytes.Buffer{}
+	parser.Interactive(true)
+	parser.Stderr(stderr)
+	parser.Stdin(strings.NewReader("5x\n3s\n"))
+
+	switch _, err := parser.Parse(); {
+	case err != nil:
+		t.Fatalf("cannot parse with prompt: %v", err)
+	case unlock.Delay != 3*time.Second:
+		t.Errorf("invalid prompt answer: %#v", unlock)
+	case !strings.Contains(stderr.String(), "error: invalid value of delay"):
+		t.Errorf("expect the generic error of the secret: %q", stderr.String())
+	case !strings.Contains(stderr.String(), "[default: ******]"):
+		t.Errorf("expect mask the default of the secret: %q", stderr.String())
+	case strings.Contains(stderr.String(), "5x"):
+		t.Errorf("expect not show the secret: %q", stderr.String())
+	}
+}
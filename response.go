@@ -0,0 +1,89 @@
+package stropt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// enable the response file on the root command, the token "@args.txt" is
+// replaced by the arguments read from the file, which follows the shell-like
+// quoting, the '#' comment and may include other response files. The leading
+// "@@" is escaped as the literal "@". Never enable it when parsing the
+// untrusted input, which may read any file the process can access.
+func (stropt *StrOpt) ResponseFile(enable bool) {
+	stropt.response_file = enable
+}
+
+// expand the response files in the arguments, the relative path in the
+// response file is related to the including file. The including chain is
+// tracked by visited to detect the cycle. The arguments after "--" are kept
+// as is, even it is from the response file.
+func (stropt *StrOpt) expandArgs(args []string, visited map[string]bool, dir string) (expanded []string, err error) {
+	for idx := 0; idx < len(args); idx++ {
+		switch token := args[idx]; {
+		case token == "--":
+			// explicit claims no options remains, never expand the remaining
+			expanded = append(expanded, args[idx:]...)
+			return
+		case strings.HasPrefix(token, "@@"):
+			// the escaped literal @
+			expanded = append(expanded, token[1:])
+		case len(token) > 1 && token[0] == '@':
+			var tokens []string
+			if tokens, err = stropt.readResponseFile(token[1:], visited, dir); err != nil {
+				return
+			}
+			expanded = append(expanded, tokens...)
+
+			for _, included := range tokens {
+				if included == "--" {
+					// the response file claims no options remains
+					expanded = append(expanded, args[idx+1:]...)
+					return
+				}
+			}
+		case strings.HasPrefix(token, "-") && stropt.hasSecretOption(strings.TrimLeft(token, "-")) && idx+1 < len(args):
+			// the secret value may be the @path, never expand as the response file
+			expanded = append(expanded, token, args[idx+1])
+			zero(args[idx+1 : idx+2])
+			idx++
+		default:
+			expanded = append(expanded, token)
+		}
+	}
+	return
+}
+
+// read the arguments from the response file and expand recursively
+func (stropt *StrOpt) readResponseFile(path string, visited map[string]bool, dir string) (tokens []string, err error) {
+	if !filepath.IsAbs(path) && dir != "" {
+		path = filepath.Join(dir, path)
+	}
+
+	var abs string
+	if abs, err = filepath.Abs(path); err != nil {
+		err = fmt.Errorf("invalid response file %v: %w", path, err)
+		return
+	} else if visited[abs] {
+		err = fmt.Errorf("response file %v included recursively", path)
+		return
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		err = fmt.Errorf("cannot read response file: %w", err)
+		return
+	} else if tokens, err = tokenize(string(data), true); err != nil {
+		err = fmt.Errorf("invalid response file %v: %w", path, err)
+		return
+	}
+
+	stropt.Debugf("expand response file %v", path)
+	visited[abs] = true
+	defer delete(visited, abs)
+
+	tokens, err = stropt.expandArgs(tokens, visited, filepath.Dir(path))
+	return
+}
@@ -0,0 +1,87 @@
+package stropt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type Compile struct {
+	Output  string   `shortcut:"o"`
+	Define  string   `shortcut:"D"`
+	Verbose bool     `shortcut:"v"`
+	Token   string   `attr:"secret"`
+	Sources []string `desc:"the source files"`
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize("# comment\n-o 'out file' \"a \\\"b\\\" \\c\" d\\ e '' x#y # tail\n-v", true)
+	if err != nil {
+		t.Fatalf("cannot tokenize: %v", err)
+	} else if expect := []string{"-o", "out file", `a "b" \c`, "d e", "", "x#y", "-v"}; strings.Join(tokens, "|") != strings.Join(expect, "|") {
+		t.Errorf("expect tokens %#v: %#v", expect, tokens)
+	}
+
+	var syntax_err *SyntaxError
+	if _, err := tokenize("-o\n  'out", true); !errors.As(err, &syntax_err) || syntax_err.Line != 2 || syntax_err.Column != 3 {
+		t.Errorf("expect unterminated quote at 2:3: %v", err)
+	}
+}
+
+func TestResponseFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"args.txt":   "-o 'out file' # the output\n@nested.txt",
+		"nested.txt": "-D \"X=1\"\n-v",
+		"cycle.txt":  "@loop.txt",
+		"loop.txt":   "@cycle.txt",
+		"rest.txt":   "-v --",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0600); err != nil {
+			t.Fatalf("cannot write %v: %v", name, err)
+		}
+	}
+
+	compile := &Compile{}
+	parser := MustNew(compile)
+
+	path := filepath.Join(dir, "args.txt")
+	if _, err := parser.Parse("@" + path); err != nil || len(compile.Sources) != 1 || compile.Sources[0] != "@"+path {
+		t.Errorf("expect not expand the response file by default (%v): %#v", err, compile)
+	}
+
+	parser.ResponseFile(true)
+	parser.SecretSource(true)
+	args := []string{"--token", "@@secret", "@" + path, "@@literal.go"}
+	switch n, err := parser.Parse(args...); {
+	case err != nil:
+		t.Fatalf("cannot parse with response file: %v", err)
+	case n != 4:
+		t.Errorf("expect consume the original arguments: %v", n)
+	case compile.Output != "out file" || compile.Define != "X=1" || !compile.Verbose || compile.Token != "@secret":
+		t.Errorf("cannot expand the response file: %#v", compile)
+	case strings.Join(compile.Sources, " ") != "@literal.go":
+		t.Errorf("expect escape the literal @: %v", compile.Sources)
+	case args[1] != "":
+		t.Errorf("expect zero the secret token: %#v", args)
+	}
+
+	if _, err := parser.Parse("-v", "--", "@"+path); err != nil {
+		t.Errorf("cannot parse after --: %v", err)
+	} else if compile.Output != "" || strings.Join(compile.Sources, " ") != "@"+path {
+		t.Errorf("expect not expand after --: %v", compile.Sources)
+	}
+
+	if _, err := parser.Parse("@"+filepath.Join(dir, "rest.txt"), "@"+path); err != nil {
+		t.Errorf("cannot parse after -- in the response file: %v", err)
+	} else if !compile.Verbose || strings.Join(compile.Sources, " ") != "@"+path {
+		t.Errorf("expect not expand after -- in the response file: %#v", compile)
+	}
+
+	if _, err := parser.Parse("@" + filepath.Join(dir, "cycle.txt")); err == nil || !strings.Contains(err.Error(), "recursively") {
+		t.Errorf("expect detect the cycle: %v", err)
+	}
+}
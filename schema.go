@@ -0,0 +1,175 @@
+package stropt
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// the serializable description of the command tree, used to build the GUI
+// launcher, documents or generate the *Struct by stropt-gen.
+type Schema struct {
+	// the name of the command
+	Name string `json:"name"`
+	// the description of the command
+	Desc string `json:"desc,omitempty"`
+	// the alias names of the sub-command
+	Aliases []string `json:"aliases,omitempty"`
+	// the order, the category and the example of the sub-command
+	Order    int    `json:"order,omitempty"`
+	Category string `json:"category,omitempty"`
+	Example  string `json:"example,omitempty"`
+	// the deprecated message, nil when not deprecated, and the callback name
+	// of the sub-command
+	Deprecated *string `json:"deprecated,omitempty"`
+	Callback   string  `json:"callback,omitempty"`
+	// the sub-command is excluded from the usage
+	Hidden bool `json:"hidden,omitempty"`
+
+	// the named options, the positional arguments and the sub-commands
+	Options   []SchemaField `json:"options,omitempty"`
+	Arguments []SchemaField `json:"arguments,omitempty"`
+	Commands  []Schema      `json:"commands,omitempty"`
+}
+
+// the serializable description of the option or the argument
+type SchemaField struct {
+	// the name, shortcut and the alias names of the field
+	Name     string   `json:"name"`
+	Shortcut string   `json:"shortcut,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+	// the field kind: flip, flag or argument
+	Kind string `json:"kind"`
+	// the type hint shown in usage and the Go type of the field
+	Hint string `json:"hint,omitempty"`
+	Type string `json:"type"`
+	// the description, the default value and the possible choices
+	Desc    string   `json:"desc,omitempty"`
+	Default string   `json:"default,omitempty"`
+	Choices []string `json:"choices,omitempty"`
+	// the deprecated message, nil when not deprecated, the option which the
+	// deprecated value forwards to and the callback name of the field
+	Deprecated *string `json:"deprecated,omitempty"`
+	Forward    string  `json:"forward,omitempty"`
+	Callback   string  `json:"callback,omitempty"`
+
+	// the attributes of the field
+	Required   bool `json:"required,omitempty"`
+	Hidden     bool `json:"hidden,omitempty"`
+	Persistent bool `json:"persistent,omitempty"`
+	Secret     bool `json:"secret,omitempty"`
+}
+
+// pre-defined kind of the field in the schema
+var (
+	// the store true/false option
+	SCHEMA_KIND_FLIP = "flip"
+	// the option with the value
+	SCHEMA_KIND_FLAG = "flag"
+	// the positional argument
+	SCHEMA_KIND_ARGUMENT = "argument"
+)
+
+// the description of the command tree from the current StrOpt
+func (stropt *StrOpt) Schema() (schema Schema) {
+	schema = Schema{
+		Name:     stropt.name,
+		Aliases:  aliases(stropt),
+		Order:    order(stropt),
+		Category: stropt.tag.Get(KEY_CATEGORY),
+		Example:  stropt.tag.Get(KEY_EXAMPLE),
+		Hidden:   stropt.field_set_attr(stropt, KEY_ATTR_HIDDEN),
+	}
+	schema.Desc, _ = stropt.tag.Lookup(KEY_DESC)
+	schema.Deprecated = deprecation(stropt)
+	schema.Callback, _ = stropt.tag.Lookup(KEY_CALLBACK)
+
+	for _, field := range stropt.fields {
+		schema.Options = append(schema.Options, stropt.schemaField(field))
+	}
+
+	for _, field := range stropt.args_fields {
+		schema.Arguments = append(schema.Arguments, stropt.schemaField(field))
+	}
+
+	for _, field := range stropt.subs() {
+		if sub, ok := field.(*StrOpt); ok {
+			schema.Commands = append(schema.Commands, sub.Schema())
+		}
+	}
+	return
+}
+
+// convert the field to the schema field
+func (stropt *StrOpt) schemaField(field Field) (schema_field SchemaField) {
+	schema_field = SchemaField{
+		Name:       field.GetName(),
+		Shortcut:   field.GetShortcut(),
+		Aliases:    aliases(field),
+		Hint:       field.Hint(),
+		Default:    stropt.defaultValue(field),
+		Choices:    field.GetChoice(),
+		Required:   stropt.field_set_attr(field, KEY_ATTR_REQUIRED),
+		Hidden:     stropt.field_set_attr(field, KEY_ATTR_HIDDEN),
+		Persistent: stropt.field_set_attr(field, KEY_ATTR_PERSISTENT),
+		Secret:     stropt.field_set_attr(field, KEY_ATTR_SECRET),
+	}
+	schema_field.Desc, _ = field.GetTag().Lookup(KEY_DESC)
+	schema_field.Deprecated = deprecation(field)
+	schema_field.Forward, _ = field.GetTag().Lookup(KEY_FORWARD)
+	schema_field.Callback, _ = field.GetTag().Lookup(KEY_CALLBACK)
+
+	var typ reflect.Type
+	switch f := field.(type) {
+	case *Flip:
+		schema_field.Kind = SCHEMA_KIND_FLIP
+		typ = f.StructField.Type
+	case *Argument:
+		schema_field.Kind = SCHEMA_KIND_ARGUMENT
+		typ = f.StructField.Type
+	case *Flag:
+		schema_field.Kind = SCHEMA_KIND_FLAG
+		typ = f.StructField.Type
+	}
+
+	if typ != nil {
+		schema_field.Type = typ.String()
+	}
+	return
+}
+
+// the deprecated message of the field, nil when not deprecated
+func deprecation(field Field) (msg *string) {
+	if v, ok := field.GetTag().Lookup(KEY_DEPRECATED); ok {
+		msg = &v
+	}
+	return
+}
+
+// the built-in schema option is enabled when not overridden by the field
+func (stropt *StrOpt) hasSchemaOption() (ok bool) {
+	_, _, found := stropt.lookupOption(OPT_SCHEMA_JSON)
+	ok = !found
+	return
+}
+
+// show the schema of the command tree as JSON on stdout, and exit
+func (stropt *StrOpt) schemaJSON() (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(stropt.Schema(), "", "  "); err != nil {
+		err = fmt.Errorf("cannot export schema: %w", err)
+		return
+	}
+
+	text := string(data) + "\n"
+	if stropt.isNoExit() {
+		err = &TextError{Err: ERR_SCHEMA, Text: text}
+		return
+	}
+
+	stropt.getStdout().Write([]byte(text)) //nolint
+	stropt.exitWith(EXIT_SUCCESS)
+	// stop the parse even the exit function returns
+	err = ERR_SCHEMA
+	return
+}
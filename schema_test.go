@@ -0,0 +1,59 @@
+package stropt
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type Repository struct {
+	Verbose bool   `shortcut:"v" desc:"verbose mode" attr:"persistent"`
+	Level   string `choice:"low high" default:"low" desc:"the level"`
+	Token   string `attr:"required hidden"`
+	Mode    string `deprecated:"" forward:"level"`
+
+	Add *struct {
+		Name *string `desc:"the remote name"`
+	} `desc:"add the remote" alias:"new" category:"Management" order:"2"`
+}
+
+func TestSchema(t *testing.T) {
+	parser := MustNew(&Repository{})
+	parser.Name("remote")
+
+	schema := parser.Schema()
+	switch {
+	case schema.Name != "remote" || len(schema.Options) != 4 || len(schema.Commands) != 1:
+		t.Fatalf("invalid schema: %#v", schema)
+	case !reflect.DeepEqual(schema.Options[0], SchemaField{Name: "verbose", Shortcut: "v", Kind: SCHEMA_KIND_FLIP, Type: "bool", Desc: "verbose mode", Persistent: true}):
+		t.Errorf("invalid flip schema: %#v", schema.Options[0])
+	case schema.Options[1].Kind != SCHEMA_KIND_FLAG || schema.Options[1].Hint != "STR" || schema.Options[1].Default != "low" || len(schema.Options[1].Choices) != 2:
+		t.Errorf("invalid flag schema: %#v", schema.Options[1])
+	case !schema.Options[2].Required || !schema.Options[2].Hidden || schema.Options[2].Deprecated != nil:
+		t.Errorf("invalid attribute schema: %#v", schema.Options[2])
+	case schema.Options[3].Deprecated == nil || *schema.Options[3].Deprecated != "" || schema.Options[3].Forward != "level":
+		t.Errorf("invalid deprecated schema: %#v", schema.Options[3])
+	}
+
+	add := schema.Commands[0]
+	switch {
+	case add.Name != "add" || add.Desc != "add the remote" || add.Category != "Management" || add.Order != 2 || len(add.Aliases) != 1:
+		t.Errorf("invalid sub-command schema: %#v", add)
+	case len(add.Arguments) != 1 || add.Arguments[0].Kind != SCHEMA_KIND_ARGUMENT || add.Arguments[0].Type != "*string":
+		t.Errorf("invalid argument schema: %#v", add.Arguments)
+	}
+
+	parser.NoExit(true)
+	var text_err *TextError
+	if _, err := parser.Parse("--token", "x", "--schema-json"); !errors.Is(err, ERR_SCHEMA) || !errors.As(err, &text_err) {
+		t.Fatalf("expect ERR_SCHEMA: %v", err)
+	}
+
+	var exported Schema
+	if err := json.Unmarshal([]byte(text_err.Text), &exported); err != nil {
+		t.Fatalf("cannot unmarshal schema: %v", err)
+	} else if exported.Name != "remote" || exported.Commands[0].Arguments[0].Name != "name" || exported.Options[3].Deprecated == nil {
+		t.Errorf("invalid exported schema: %#v", exported)
+	}
+}
@@ -0,0 +1,153 @@
+package stropt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// the default value shown in the usage and schema, masked for the secret
+func (stropt *StrOpt) defaultValue(field Field) (_default string) {
+	_default = field.Default()
+	if _default != "" && stropt.field_set_attr(field, KEY_ATTR_SECRET) {
+		_default = SECRET_MASK
+	}
+	return
+}
+
+// enable reading the secret value from the stdin when the value is "-" and
+// from the file when the value is "@path", and the leading "@@" is escaped as
+// the literal "@". Never enable it when parsing the untrusted input, which
+// may read any file the process can access.
+func (stropt *StrOpt) SecretSource(enable bool) {
+	stropt.secret_source = enable
+}
+
+// check the secret source is enabled, inherit from the parent
+func (stropt *StrOpt) isSecretSource() (enable bool) {
+	enable = stropt.secret_source || (stropt.parent != nil && stropt.parent.isSecretSource())
+	return
+}
+
+// resolve the value of the secret field from the stdin or the file when the
+// secret source is enabled, otherwise the value is used as is.
+func (stropt *StrOpt) secretArgs(field Field, args []string) (resolved []string, err error) {
+	if _, flip := field.(*Flip); flip || len(args) == 0 || !stropt.isSecretSource() {
+		// no value consumed
+		resolved = args
+		return
+	}
+
+	resolved = append([]string{}, args...)
+	switch value := args[0]; {
+	case value == "-":
+		if resolved[0], err = readLine(stropt.getStdin()); err != nil {
+			err = fmt.Errorf("cannot read %v from stdin: %w", field.GetName(), err)
+			return
+		}
+	case strings.HasPrefix(value, "@@"):
+		resolved[0] = value[1:]
+	case strings.HasPrefix(value, "@"):
+		var data []byte
+		if data, err = os.ReadFile(value[1:]); err != nil {
+			err = fmt.Errorf("cannot read %v from file: %w", field.GetName(), err)
+			return
+		}
+
+		resolved[0] = strings.TrimRight(string(data), "\r\n")
+		for idx := range data {
+			// clean the secret in the buffer
+			data[idx] = 0
+		}
+	}
+	return
+}
+
+// mask the values of the secret options and arguments, used in the trace.
+// The positional tokens are bound to the arguments as the parse does, and
+// only the value bound to the secret argument is masked.
+func (stropt *StrOpt) maskArgs(args []string) (masked []string) {
+	masked = append([]string{}, args...)
+
+	current, args_idx, no_option := stropt, 0, false
+	for idx := 0; idx < len(masked); idx++ {
+		switch token := masked[idx]; {
+		case token == "--":
+			no_option = true
+		case !no_option && (strings.HasPrefix(token, "--") || len(token) == 2 && token[0] == '-'):
+			owner, field := current.maskOption(strings.TrimLeft(token, "-"))
+			if _, flip := field.(*Flip); field != nil && !flip && idx+1 < len(masked) {
+				// the next token is the value of the option
+				idx++
+				if owner.field_set_attr(field, KEY_ATTR_SECRET) {
+					masked[idx] = SECRET_MASK
+				}
+			}
+		case !no_option && strings.HasPrefix(token, "-") && len(token) > 1:
+			// the multiple shortcuts never consume the value
+		default:
+			if sub := current.maskSub(token, args_idx); sub != nil {
+				// switch to the selected sub-command
+				current, args_idx, no_option = sub, 0, false
+				continue
+			}
+
+			if args_idx < len(current.args_fields) {
+				if field := current.args_fields[args_idx]; current.field_set_attr(field, KEY_ATTR_SECRET) {
+					masked[idx] = SECRET_MASK
+				}
+				args_idx++
+			}
+		}
+	}
+	return
+}
+
+// the option resolved in maskArgs, which may be the option of the parent
+// command in the chain mode.
+func (stropt *StrOpt) maskOption(name string) (owner *StrOpt, field Field) {
+	for current := stropt; current != nil; current = current.parent {
+		if found, found_field, ok := current.lookupOption(name); ok {
+			owner, field = found, found_field
+			return
+		} else if !current.isChained() {
+			return
+		}
+	}
+	return
+}
+
+// the sub-command selected by the token in maskArgs, which may be the next
+// chained sub-command of the parent when no argument is pending.
+func (stropt *StrOpt) maskSub(token string, args_idx int) (sub *StrOpt) {
+	switch {
+	case stropt.sub_fields[token] != nil:
+		sub, _ = stropt.sub_fields[token].(*StrOpt)
+	case args_idx >= len(stropt.args_fields) && stropt.isChained():
+		sub, _ = stropt.parent.sub_fields[token].(*StrOpt)
+	}
+	return
+}
+
+// check the option, resolvable in the command or the sub-commands, is secret
+func (stropt *StrOpt) hasSecretOption(name string) (ok bool) {
+	if _, field, found := stropt.lookupOption(name); found && stropt.field_set_attr(field, KEY_ATTR_SECRET) {
+		ok = true
+		return
+	}
+
+	for _, field := range stropt.sub_list {
+		if sub, _ := field.(*StrOpt); sub != nil && sub.hasSecretOption(name) {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// zero the tokens which are already consumed
+func zero(tokens []string) {
+	for idx := range tokens {
+		tokens[idx] = ""
+	}
+}
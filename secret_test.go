@@ -0,0 +1,164 @@
+package stropt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type Credential struct {
+	User     string `shortcut:"u"`
+	Password string `shortcut:"p" attr:"secret" default:"changeme" desc:"the password"`
+	PIN      int    `name:"pin" attr:"secret"`
+}
+
+func TestSecret(t *testing.T) {
+	credential := &Credential{}
+	parser := MustNew(credential)
+
+	buff := &bytes.Buffer{}
+	parser.Usage(buff)
+	if text := buff.String(); strings.Contains(text, "changeme") || !strings.Contains(text, "[default: ******]") {
+		t.Errorf("expect mask the default secret: %v", text)
+	} else if schema := parser.Schema(); schema.Options[1].Default != SECRET_MASK || !schema.Options[1].Secret {
+		t.Errorf("expect mask the default secret in schema: %#v", schema.Options[1])
+	}
+
+	args := []string{"-u", "admin", "--password", "p@ss", "--pin", "1234"}
+	if _, err := parser.Parse(args...); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if credential.Password != "p@ss" || credential.PIN != 1234 {
+		t.Errorf("cannot parse secret: %#v", credential)
+	} else if strings.Join(args, " ") != "-u admin --password  --pin " {
+		t.Errorf("expect zero the secret tokens: %#v", args)
+	} else if masked := parser.maskArgs([]string{"-u", "admin", "-p", "x", "--pin", "1"}); strings.Join(masked, " ") != "-u admin -p ****** --pin ******" {
+		t.Errorf("expect mask the secret in trace: %v", masked)
+	}
+
+	if _, err := parser.Parse("--pin", "abc"); err == nil || strings.Contains(err.Error(), "abc") {
+		t.Errorf("expect mask the secret in error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("cannot write secret: %v", err)
+	}
+
+	if _, err := parser.Parse("-p", "@"+path); err != nil || credential.Password != "@"+path {
+		t.Errorf("expect not read the secret source by default (%v): %#v", err, credential)
+	}
+
+	parser.SecretSource(true)
+	parser.Stdin(strings.NewReader("from-stdin\n"))
+	if _, err := parser.Parse("-p", "@"+path); err != nil || credential.Password != "from-file" {
+		t.Errorf("cannot read secret from file (%v): %#v", err, credential)
+	} else if _, err := parser.Parse("-p", "-"); err != nil || credential.Password != "from-stdin" {
+		t.Errorf("cannot read secret from stdin (%v): %#v", err, credential)
+	} else if _, err := parser.Parse("-p", "@@literal"); err != nil || credential.Password != "@literal" {
+		t.Errorf("cannot escape the literal @ (%v): %#v", err, credential)
+	}
+}
+
+type VaultLogin struct {
+	User *string
+	PIN  *int `name:"pin" attr:"secret"`
+}
+
+type Vault struct {
+	Verbose bool    `shortcut:"v"`
+	Level   string  `shortcut:"l"`
+	Token   *string `attr:"secret" callback:"Token_"`
+	Name    *string
+
+	*VaultLogin `name:"login"`
+
+	ctx *CallbackContext
+}
+
+func (vault *Vault) Token_(ctx *CallbackContext) (err error) {
+	vault.ctx = ctx
+	return
+}
+
+func TestSecretArgument(t *testing.T) {
+	vault := &Vault{}
+	parser := MustNew(vault)
+
+	for args, expect := range map[string]string{
+		"-v s3cret name":               "-v ****** name",
+		"-l info s3cret name":          "-l info ****** name",
+		"login admin 1234":             "login admin ******",
+		"s3cret name login admin 1234": "****** name login admin ******",
+		"-- -s3cret name":              "-- ****** name",
+	} {
+		if masked := parser.maskArgs(strings.Fields(args)); strings.Join(masked, " ") != expect {
+			t.Errorf("expect mask %#v as %#v: %#v", args, expect, masked)
+		}
+	}
+
+	if _, err := parser.Parse("s3cret", "name"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if vault.Token == nil || *vault.Token != "s3cret" || vault.ctx == nil {
+		t.Fatalf("cannot parse secret argument: %#v", vault)
+	} else if vault.ctx.Token != SECRET_MASK {
+		t.Errorf("expect mask the token in callback: %#v", vault.ctx.Token)
+	}
+
+	if _, err := parser.Parse("login", "admin", "abc"); err == nil || strings.Contains(err.Error(), "abc") {
+		t.Errorf("expect mask the secret argument in error: %v", err)
+	}
+}
+
+type Registry struct {
+	Login *struct {
+		User  *string
+		Token *string `attr:"secret"`
+	}
+	Logout *struct {
+		User *string
+	}
+}
+
+func TestSecretArgumentSub(t *testing.T) {
+	parser := MustNew(&Registry{})
+
+	for args, expect := range map[string]string{
+		"login admin s3cret": "login admin ******",
+		"logout admin":       "logout admin",
+		"logout login":       "logout login",
+	} {
+		if masked := parser.maskArgs(strings.Fields(args)); strings.Join(masked, " ") != expect {
+			t.Errorf("expect mask %#v as %#v: %#v", args, expect, masked)
+		}
+	}
+}
+
+func TestSecretTrace(t *testing.T) {
+	vault := &Vault{}
+	parser := MustNew(vault)
+
+	var messages []string
+	parser.trace_hook = func(msg string) {
+		messages = append(messages, msg)
+	}
+
+	for _, args := range [][]string{
+		{"--level", "info", "s3cret", "name"},
+		{"-v", "login", "admin", "9876"},
+	} {
+		if _, err := parser.Parse(args...); err != nil {
+			t.Fatalf("cannot parse %v: %v", args, err)
+		}
+	}
+
+	switch text := strings.Join(messages, "\n"); {
+	case vault.PIN == nil || *vault.PIN != 9876:
+		t.Errorf("cannot parse the secret: %#v", vault)
+	case !strings.Contains(text, "parse [info] on level"):
+		t.Errorf("expect trace the consumed values:\n%v", text)
+	case strings.Contains(text, "s3cret") || strings.Contains(text, "9876"):
+		t.Errorf("expect no secret in the trace:\n%v", text)
+	}
+}
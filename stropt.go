@@ -1,14 +1,17 @@
 package stropt
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/cmj0121/trace"
@@ -21,9 +24,12 @@ type StrOpt struct {
 
 	// the shadow of the value, create and copy to original value
 	shadow reflect.Value
+	// the initial value of the sub-command before parse
+	initial reflect.Value
 
-	// the log sub-system
+	// the log sub-system, and the hook receives the formatted message
 	*trace.Tracer
+	trace_hook func(msg string)
 
 	// name of the stropt, always be the lowercase
 	name string
@@ -39,6 +45,49 @@ type StrOpt struct {
 	args_idx    int
 	// the named sub-command
 	sub_fields map[string]Field
+	// the sub-command, append by the order
+	sub_list []Field
+	// the sub-commands selected in the last parse, more than one when chained
+	selected []*StrOpt
+	// the parent command of the sub-command
+	parent *StrOpt
+
+	// the output and exit environment, inherit from the parent if not set
+	stdout io.Writer
+	stderr io.Writer
+	exit   func(code int)
+	// the terminal width of the usage, detect when not set
+	width int
+	// the style of the usage and error message, inherit from the parent
+	style *Style
+	// the customized usage template, inherit from the parent
+	template *template.Template
+	// the text shown before and after the usage
+	prolog string
+	epilog string
+	// the example of the command, override the example tag
+	example string
+	// return the sentinel error instead of exit in the built-in callback
+	no_exit bool
+	// allow multiple chained sub-commands in the single invocation
+	chain bool
+	// prompt the missing required value on the terminal, and the reader of
+	// the answer, inherit from the parent
+	interactive bool
+	stdin       io.Reader
+	// expand the @file token to the arguments read from the file
+	response_file bool
+	// read the secret value from the stdin or the file, inherit from the parent
+	secret_source bool
+	// the callbacks scoped on the StrOpt, inherited by sub-commands
+	callbacks map[string]Callback
+	// the callbacks triggered after the whole command-line consumed
+	complete_callbacks []string
+	// the deprecated fields already warned in the current parse
+	deprecated map[Field]bool
+	// the value of each field after the last parse
+	parsed map[Field]interface{}
+
 	// the version info
 	version string
 }
@@ -90,103 +139,131 @@ func (stropt *StrOpt) Name(name string) {
 	stropt.name = strings.ToLower(name)
 }
 
-// write the usage to the pass io.Writer
-func (stropt *StrOpt) Usage(w io.Writer) {
-	buff := &bytes.Buffer{}
-
-	var usage []string
-
-	switch {
-	case len(stropt.fields) > 0 && len(stropt.args_fields) > 0:
-		usage = append(usage, fmt.Sprintf("usage: %v [OPTION] [ARGS] ...", stropt.name))
-		usage = append(usage, "")
-	case len(stropt.fields) > 0:
-		usage = append(usage, fmt.Sprintf("usage: %v [OPTION]", stropt.name))
-		usage = append(usage, "")
-	case len(stropt.args_fields) > 0:
-		usage = append(usage, fmt.Sprintf("usage: %v [ARGS] ...", stropt.name))
-		usage = append(usage, "")
-	default:
-		usage = append(usage, fmt.Sprintf("usage: %v", stropt.name))
-		usage = append(usage, "")
-	}
-
-	if len(stropt.fields) > 0 {
-		usage = append(usage, "options:")
-		for _, field := range stropt.fields {
-			usage = append(usage, stropt.description(field, false))
-		}
-		usage = append(usage, "")
-	}
-
-	if len(stropt.args_fields) > 0 {
-		usage = append(usage, "arguments:")
-		for _, field := range stropt.args_fields {
-			usage = append(usage, stropt.description(field, true))
+// the parent commands shown before the command name in usage
+func (stropt *StrOpt) usagePrefix() (prefix string) {
+	if parent := stropt.parent; parent != nil {
+		prefix = parent.usagePrefix() + parent.name + " "
+		if len(parent.visible(parent.fields)) > 0 {
+			prefix += "[GLOBAL OPTIONS] "
 		}
-		usage = append(usage, "")
 	}
-
-	if len(stropt.sub_fields) > 0 {
-		usage = append(usage, "sub-commands:")
-		for _, field := range stropt.sub_fields {
-			usage = append(usage, stropt.description(field, true))
-		}
-		usage = append(usage, "")
-	}
-
-	buff.WriteString(strings.Join(usage, "\n"))
-	w.Write(buff.Bytes()) // nolint
+	return
 }
 
 // show the error message and usage
 func (stropt *StrOpt) ErrorAndUsage(err error, w io.Writer) {
-	w.Write([]byte(fmt.Sprintf("error: %v\n", err))) //nolint
+	stropt.writeError(w, err)
 	stropt.Usage(w)
 }
 
+// write the error message with the styled prefix
+func (stropt *StrOpt) writeError(w io.Writer, err error) {
+	style := stropt.getStyle(w)
+	w.Write([]byte(fmt.Sprintf("%v %v\n", style.Apply(style.Error, "error:"), err))) //nolint
+}
+
 // parse the input arguments and fill the *Struct, return error when failure.
+//
+// the StrOpt is reset before parse, so the Parse is safe to be called repeatedly
+// and each call starts from the default/initial value. The value set on the
+// *Struct by the caller after New or the last Parse is kept as the initial value.
 func (stropt *StrOpt) Parse(args ...string) (n int, err error) {
-	stropt.Tracef("start parse: %v", args)
+	masked := stropt.maskArgs(args)
+	stropt.Tracef("start parse: %v", masked)
+	// record after the sub-command materialized
+	defer stropt.record()
 	defer func() {
-		if stropt.shadow.IsValid() && !stropt.shadow.IsZero() {
-			// copy the shadow value to current value
+		if stropt.shadow.IsValid() {
+			// always materialize the selected sub-command, even all fields are zero
 			stropt.Value.Set(stropt.shadow)
 		}
 	}()
 
+	if err = stropt.snapshot(); err != nil {
+		err = fmt.Errorf("cannot snapshot %v: %w", stropt.name, err)
+		return
+	} else if err = stropt.Reset(); err != nil {
+		err = fmt.Errorf("cannot reset %v: %w", stropt.name, err)
+		return
+	}
+
+	if stropt.response_file && stropt.parent == nil {
+		// the response file is only expanded on the root command
+		origin := len(args)
+		if args, err = stropt.expandArgs(args, map[string]bool{}, ""); err != nil {
+			return
+		}
+		masked = stropt.maskArgs(args)
+		stropt.Tracef("expand response file: %v", masked)
+
+		// the number of the consumed arguments is the original one
+		defer func() {
+			if err == nil {
+				n = origin
+			}
+		}()
+	}
+
+	if err = stropt.beforeParse(); err != nil {
+		// the hook stop the parse
+		return
+	}
+
 	no_option := false
 	idx := 0
+PARSE:
 	for idx < len(args) {
 		nargs := 0
 		token := args[idx]
-		stropt.Debugf("parse #%v: %v", idx, token)
+		stropt.Debugf("parse #%v: %v", idx, masked[idx])
 
 		switch {
 		case token == "--":
 			no_option = true
 			stropt.Infof("explicit claims no options remains")
+		case !no_option && token == CMD_HELP && stropt.hasHelpCommand() && stropt.args_idx >= len(stropt.args_fields):
+			// the built-in help command, show the help without execute anything,
+			// only when no positional argument is pending
+			err = stropt.helpCommand(args[idx+1:]...)
+			return
+		case !no_option && token == "--"+OPT_SCHEMA_JSON && stropt.hasSchemaOption():
+			// the built-in hidden option, show the schema without execute anything
+			err = stropt.schemaJSON()
+			return
 		case !no_option && len(token) > 2 && token[:2] == "--":
-			field, ok := stropt.named_fields[token[2:]]
-			if !ok {
+			owner, field, ok := stropt.lookupOption(token[2:])
+			switch {
+			case !ok && stropt.isChained():
+				// may the option of the parent command, stop and return to the parent
+				stropt.Debugf("option %v not found, return to %v", token, stropt.parent.name)
+				break PARSE
+			case !ok:
 				err = fmt.Errorf("option %v not found", token)
 				return
-			} else if nargs, err = stropt.parse(field, args[idx+1:]...); err != nil {
-				err = fmt.Errorf("parse %v fail: %v", token, err)
+			}
+
+			if nargs, err = owner.parse(token, field, args[idx+1:]...); err != nil {
+				err = fmt.Errorf("parse %v fail: %w", token, err)
 				return
 			}
 
 			idx += nargs
 		case !no_option && len(token) > 1 && token[:1] == "-":
+			if _, _, ok := stropt.lookupOption(string([]rune(token)[1])); !ok && stropt.isChained() {
+				// may the option of the parent command, stop and return to the parent
+				stropt.Debugf("option %v not found, return to %v", token, stropt.parent.name)
+				break PARSE
+			}
+
 			switch len(token) {
 			case 2:
 				// single shortcut
-				field, ok := stropt.named_fields[token[1:]]
+				owner, field, ok := stropt.lookupOption(token[1:])
 				if !ok {
 					err = fmt.Errorf("option %v not found", token)
 					return
-				} else if nargs, err = stropt.parse(field, args[idx+1:]...); err != nil {
-					err = fmt.Errorf("parse %v fail: %v", token, err)
+				} else if nargs, err = owner.parse(token, field, args[idx+1:]...); err != nil {
+					err = fmt.Errorf("parse %v fail: %w", token, err)
 					return
 				}
 
@@ -194,36 +271,47 @@ func (stropt *StrOpt) Parse(args ...string) (n int, err error) {
 			default:
 				// multiple shortcut
 				for _, shortcut := range token[1:] {
-					field, ok := stropt.named_fields[string(shortcut)]
+					owner, field, ok := stropt.lookupOption(string(shortcut))
 					if !ok {
 						err = fmt.Errorf("option -%v not found", token)
 						return
-					} else if _, err = stropt.parse(field); err != nil {
-						err = fmt.Errorf("parse -%v fail: %v", shortcut, err)
+					} else if _, err = owner.parse("-"+string(shortcut), field); err != nil {
+						err = fmt.Errorf("parse -%c fail: %w", shortcut, err)
 						return
 					}
 				}
 			}
 		default:
-			switch field, ok := stropt.sub_fields[token]; ok {
-			case true:
-				// sub-command
-				if _, err = stropt.parse(field, args[idx+1:]...); err != nil {
-					err = fmt.Errorf("parse %v fail: %v", token, err)
-					return
-				}
-
+			switch field, ok := stropt.sub_fields[token]; {
+			case ok && len(stropt.selected) > 0 && !stropt.isChain():
+				err = fmt.Errorf("unknown argument: %v", token)
 				return
-			case false:
-				// position field
-				if stropt.args_idx >= len(stropt.args_fields) {
-					err = fmt.Errorf("unknown argument: %v", token)
+			case ok:
+				// sub-command, selected before trigger the callback
+				sub, _ := field.(*StrOpt)
+				stropt.selected = append(stropt.selected, sub)
+				if nargs, err = stropt.parse(token, field, args[idx+1:]...); err != nil {
+					err = fmt.Errorf("parse %v fail: %w", token, err)
 					return
 				}
 
+				// the sub-command itself
+				nargs++
+			case stropt.args_idx >= len(stropt.args_fields) && stropt.isChained() && stropt.parent.sub_fields[token] != nil:
+				// the next chained sub-command, stop and return to the parent
+				stropt.Debugf("sub-command %v chained, return to %v", token, stropt.parent.name)
+				break PARSE
+			case stropt.args_idx >= len(stropt.args_fields):
+				err = fmt.Errorf("unknown argument: %v", token)
+				return
+			default:
+				// position field, the token is the value itself and masked for the secret
 				field = stropt.args_fields[stropt.args_idx]
-				if nargs, err = stropt.parse(field, args[idx:]...); err != nil {
-					err = fmt.Errorf("parse %v fail: %v", token, err)
+				if stropt.field_set_attr(field, KEY_ATTR_SECRET) {
+					token = SECRET_MASK
+				}
+				if nargs, err = stropt.parse(token, field, args[idx:]...); err != nil {
+					err = fmt.Errorf("parse %v fail: %w", token, err)
 					return
 				}
 
@@ -238,29 +326,334 @@ func (stropt *StrOpt) Parse(args ...string) (n int, err error) {
 		idx++
 	}
 
+	// the number of the consumed arguments
+	n = idx
+
 	// run the final check after parse the pass options
-	err = stropt.epologue()
+	if err = stropt.epologue(); err != nil {
+		return
+	} else if err = stropt.complete(); err != nil {
+		return
+	}
+
+	err = stropt.afterParse()
+	return
+}
+
+// split the single command-line by the POSIX shell quoting rules and parse,
+// the quoting error is the *SyntaxError with the line and column position.
+// The returned n is the number of the consumed tokens, not the offset in the
+// line, and the '#' is the normal character instead of the shell comment.
+//
+// the ResponseFile and SecretSource read the local files, keep them disabled
+// when the line is the untrusted input like the chat message or the RPC.
+func (stropt *StrOpt) ParseString(line string) (n int, err error) {
+	var args []string
+	if args, err = tokenize(line, false); err != nil {
+		return
+	}
+
+	n, err = stropt.Parse(args...)
+	return
+}
+
+// restore every field to the default/initial value, rewind the positional
+// state and clean the selected sub-command.
+func (stropt *StrOpt) Reset() (err error) {
+	stropt.Tracef("reset %v", stropt.name)
+
+	stropt.args_idx = 0
+	stropt.selected = nil
+	stropt.deprecated = map[Field]bool{}
+	if stropt.initial.IsValid() {
+		// restore the sub-command to the initial value
+		stropt.Value.Set(stropt.initial)
+	}
+
+	for _, field := range stropt.fields {
+		if err = stropt.reset(field); err != nil {
+			return
+		}
+	}
+
+	for _, field := range stropt.args_fields {
+		if err = stropt.reset(field); err != nil {
+			return
+		}
+	}
+
+	for _, field := range stropt.subs() {
+		if err = field.Reset(); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// restore the field to the initial value, which already contains the default
+func (stropt *StrOpt) reset(field Field) (err error) {
+	if err = field.Reset(); err != nil {
+		err = fmt.Errorf("reset %v: %w", field.GetName(), err)
+	}
+	return
+}
+
+// keep the value changed by the caller after New or the last Parse as the
+// initial value, so Reset never overwrite the value set by the caller.
+func (stropt *StrOpt) snapshot() (err error) {
+	for _, fields := range [][]Field{stropt.fields, stropt.args_fields, stropt.sub_list} {
+		for _, field := range fields {
+			value, ok := stropt.parsed[field]
+			if ok && reflect.DeepEqual(value, boundValue(field).Interface()) {
+				// not changed since the last parse
+				continue
+			}
+
+			if err = field.Snapshot(); err != nil {
+				err = fmt.Errorf("snapshot %v: %w", field.GetName(), err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// record the value of each field after parse, used to detect the change
+func (stropt *StrOpt) record() {
+	stropt.parsed = map[Field]interface{}{}
+	for _, fields := range [][]Field{stropt.fields, stropt.args_fields, stropt.sub_list} {
+		for _, field := range fields {
+			stropt.parsed[field] = boundValue(field).Interface()
+		}
+	}
+}
+
+// the value bound on the *Struct, the pointer field for the sub-command
+func boundValue(field Field) (value reflect.Value) {
+	switch field := field.(type) {
+	case *StrOpt:
+		value = field.Value
+	default:
+		value = fieldValue(field)
+	}
+	return
+}
+
+// lookup the named option, include the persistent options of the parent
+// commands which are parsed and set by the parent.
+func (stropt *StrOpt) lookupOption(name string) (owner *StrOpt, field Field, ok bool) {
+	if field, ok = stropt.named_fields[name]; ok {
+		owner = stropt
+		return
+	}
+
+	for parent := stropt.parent; parent != nil; parent = parent.parent {
+		if field, ok = parent.named_fields[name]; ok && parent.field_set_attr(field, KEY_ATTR_PERSISTENT) {
+			stropt.Debugf("found persistent option %v in %v", name, parent.name)
+			owner = parent
+			return
+		}
+	}
+
+	ok = false
+	return
+}
+
+// the persistent options of the parent commands
+func (stropt *StrOpt) persistent() (fields []Field) {
+	if parent := stropt.parent; parent != nil {
+		fields = parent.persistent()
+
+		for _, field := range parent.fields {
+			if parent.field_set_attr(field, KEY_ATTR_PERSISTENT) {
+				fields = append(fields, field)
+			}
+		}
+	}
 	return
 }
 
 // the helper utility for parse the arguments and trigger callback with specified field
-func (stropt *StrOpt) parse(field Field, args ...string) (n int, err error) {
-	stropt.Debugf("parse %v on %v", args, field)
-	if n, err = field.Parse(args...); err == nil {
-		if name, ok := field.GetTag().Lookup(KEY_CALLBACK); ok {
-			// call the callback function
-			err = CallCallback(name, stropt, field)
+func (stropt *StrOpt) parse(token string, field Field, args ...string) (n int, err error) {
+	if _, ok := field.(*StrOpt); ok {
+		// the sub-command is selected, warn and trigger the callback before
+		// the sub-command parse the remaining arguments
+		if err = stropt.deprecate(token, field); err != nil {
+			return
+		} else if name, ok := field.GetTag().Lookup(KEY_CALLBACK); ok {
+			ctx := stropt.newCallbackContext(token, field)
+			if err = stropt.callCallback(name, ctx); err != nil {
+				return
+			}
+		}
+
+		n, err = field.Parse(args...)
+		return
+	}
+
+	secret := stropt.field_set_attr(field, KEY_ATTR_SECRET)
+	if secret {
+		raw := args
+		stropt.Debugf("parse secret on %v", field.GetName())
+		if args, err = stropt.secretArgs(field, args); err != nil {
+			return
+		}
+
+		defer func() {
+			// never keep the secret in the tokens after use, even failure
+			size := n
+			if err != nil && len(args) > 0 {
+				size = 1
+			}
+			zero(raw[:size])
+			zero(args[:size])
+		}()
+	}
+
+	if n, err = field.Parse(args...); err != nil {
+		return
+	} else if !secret {
+		// only the consumed values, the remaining arguments may be the secret
+		stropt.Debugf("parse %v on %v", args[:n], field.GetName())
+	}
+
+	if err = stropt.deprecate(token, field, args[:n]...); err != nil {
+		return
+	}
+
+	if name, ok := field.GetTag().Lookup(KEY_CALLBACK); ok {
+		// call the callback function
+		ctx := stropt.newCallbackContext(token, field, args[:n]...)
+		err = stropt.callCallback(name, ctx)
+	}
+
+	return
+}
+
+// warn the deprecated field once in the parse, and forward the consumed
+// arguments to the replacement option if set.
+func (stropt *StrOpt) deprecate(token string, field Field, args ...string) (err error) {
+	msg, ok := field.GetTag().Lookup(KEY_DEPRECATED)
+	if !ok {
+		// not the deprecated field
+		return
+	}
+
+	if !stropt.deprecated[field] {
+		stropt.deprecated[field] = true
+
+		// the field name, the token of the argument is the value itself
+		name := field.GetName()
+		if _, argument := field.(*Argument); !argument {
+			name = "--" + name
+		}
+
+		warning := fmt.Sprintf("warning: %v is deprecated", name)
+		if msg != "" {
+			warning = fmt.Sprintf("%v, %v", warning, msg)
+		}
+		stropt.getStderr().Write([]byte(warning + "\n")) //nolint
+	}
+
+	if name, ok := field.GetTag().Lookup(KEY_FORWARD); ok {
+		// already validated when New, and parse with the choice and callback
+		target := stropt.named_fields[name]
+
+		stropt.Infof("forward %v to %v", field.GetName(), name)
+		if _, err = stropt.parse(token, target, args...); err != nil {
+			err = fmt.Errorf("forward to %v: %w", name, err)
+			return
+		}
+	}
+
+	return
+}
+
+// the selected sub-commands in the last parse, return the path joined by the
+// space like "remote add" and the StrOpt of each sub-command. The chained
+// sub-commands are listed by the order.
+func (stropt *StrOpt) Selected() (path string, commands []*StrOpt) {
+	var names []string
+
+	for _, sub := range stropt.selected {
+		sub_path, sub_commands := sub.Selected()
+
+		names = append(names, sub.name)
+		if sub_path != "" {
+			names = append(names, sub_path)
+		}
+
+		commands = append(commands, sub)
+		commands = append(commands, sub_commands...)
+	}
+
+	path = strings.Join(names, " ")
+	return
+}
+
+// the built-in help command is enabled when has sub-commands and not
+// overridden by the sub-command
+func (stropt *StrOpt) hasHelpCommand() (ok bool) {
+	_, found := stropt.sub_fields[CMD_HELP]
+	ok = len(stropt.sub_list) > 0 && !found
+	return
+}
+
+// show the help message of the sub-command path
+func (stropt *StrOpt) helpCommand(names ...string) (err error) {
+	target := stropt
+	for _, name := range names {
+		sub, ok := target.sub_fields[name].(*StrOpt)
+		if !ok {
+			err = fmt.Errorf("unknown sub-command: %v", name)
+			return
 		}
+
+		target = sub
+	}
+
+	err = help(target, nil)
+	return
+}
+
+// the command names from the root command to the current StrOpt
+func (stropt *StrOpt) path() (names []string) {
+	if stropt.parent != nil {
+		names = stropt.parent.path()
 	}
 
+	names = append(names, stropt.name)
 	return
 }
 
-// parse from the command-line arguments
+// parse from the command-line arguments and run the selected command, exit
+// with the code carried by the error when failure.
 func (stropt *StrOpt) Run() {
 	if _, err := stropt.Parse(os.Args[1:]...); err != nil {
-		stropt.ErrorAndUsage(err, os.Stderr)
-		os.Exit(1)
+		var text_err *TextError
+
+		switch {
+		case errors.As(err, &text_err) && errors.Is(err, ERR_HELP):
+			// the no-exit mode, show the help message
+			stropt.getStderr().Write([]byte(text_err.Text)) //nolint
+			stropt.exitWith(EXIT_FAILURE)
+		case errors.As(err, &text_err):
+			// the no-exit mode, show the version or schema
+			stropt.getStdout().Write([]byte(text_err.Text)) //nolint
+			stropt.exitWith(EXIT_SUCCESS)
+		case errors.Is(err, ERR_HELP), errors.Is(err, ERR_VERSION), errors.Is(err, ERR_SCHEMA):
+			// already shown by the built-in callback
+		default:
+			stropt.ErrorAndUsage(err, stropt.getStderr())
+			stropt.exitWith(ExitCode(err, EXIT_USAGE))
+		}
+		return
+	}
+
+	if err := stropt.executeContext(context.Background()); err != nil {
+		stropt.writeError(stropt.getStderr(), err)
+		stropt.exitWith(ExitCode(err, EXIT_FAILURE))
 	}
 }
 
@@ -287,6 +680,31 @@ func (stropt *StrOpt) prologue(value reflect.Value, typ reflect.Type) (err error
 			}
 		}
 	}
+
+	err = stropt.setForward()
+	return
+}
+
+// validate the forward target of the deprecated fields, which should be the
+// option of the same command
+func (stropt *StrOpt) setForward() (err error) {
+	for _, fields := range [][]Field{stropt.fields, stropt.args_fields} {
+		for _, field := range fields {
+			name, ok := field.GetTag().Lookup(KEY_FORWARD)
+			if !ok {
+				continue
+			}
+
+			switch target, ok := stropt.named_fields[name]; {
+			case !ok:
+				err = fmt.Errorf("forward option of %v not found: %v", field.GetName(), name)
+				return
+			case target == field:
+				err = fmt.Errorf("cannot forward %v to itself", field.GetName())
+				return
+			}
+		}
+	}
 	return
 }
 
@@ -296,6 +714,14 @@ func (stropt *StrOpt) epologue() (err error) {
 
 	for _, field := range stropt.fields {
 		if stropt.field_set_required(field) && field.IsZero() {
+			if stropt.canPrompt(field) {
+				// ask the missing value instead of failure
+				if err = stropt.prompt(field); err != nil {
+					return
+				}
+				continue
+			}
+
 			err = fmt.Errorf("option %#v is required but not set", field.GetName())
 			return
 		}
@@ -303,6 +729,14 @@ func (stropt *StrOpt) epologue() (err error) {
 
 	for _, field := range stropt.args_fields {
 		if stropt.field_set_required(field) && field.IsZero() {
+			if stropt.canPrompt(field) {
+				// ask the missing value instead of failure
+				if err = stropt.prompt(field); err != nil {
+					return
+				}
+				continue
+			}
+
 			err = fmt.Errorf("option %#v is required but not set", field.GetName())
 			return
 		}
@@ -311,21 +745,93 @@ func (stropt *StrOpt) epologue() (err error) {
 	return
 }
 
+// trigger the callbacks after the whole command-line consumed
+func (stropt *StrOpt) complete() (err error) {
+	for _, name := range stropt.complete_callbacks {
+		ctx := stropt.newCallbackContext("", nil)
+		if err = stropt.callCallback(name, ctx); err != nil {
+			err = fmt.Errorf("complete callback %v: %w", name, err)
+			return
+		}
+	}
+	return
+}
+
 func (stropt *StrOpt) field_set_required(field Field) (set bool) {
-	tag := field.GetTag()
+	if set = stropt.field_set_attr(field, KEY_ATTR_REQUIRED); set {
+		stropt.Debugf("detect %#v set required", field.GetName())
+	}
+	return
+}
 
-	if v, ok := tag.Lookup(KEY_ATTR); ok {
-		// check all the required field already set or not
+// check the field set the attribute or not
+func (stropt *StrOpt) field_set_attr(field Field, attr string) (set bool) {
+	if v, ok := field.GetTag().Lookup(KEY_ATTR); ok {
 		attrs := strings.Split(v, " ")
 		sort.Strings(attrs)
 
-		idx := sort.SearchStrings(attrs, KEY_ATTR_REQUIRED)
-		if idx >= 0 && idx < len(attrs) && attrs[idx] == KEY_ATTR_REQUIRED {
-			stropt.Debugf("detect %#v set required", field.GetName())
-			set = true
+		idx := sort.SearchStrings(attrs, attr)
+		set = idx >= 0 && idx < len(attrs) && attrs[idx] == attr
+	}
+
+	return
+}
+
+// the fields without the hidden attribute
+func (stropt *StrOpt) visible(fields []Field) (visible []Field) {
+	for _, field := range fields {
+		if !stropt.field_set_attr(field, KEY_ATTR_HIDDEN) {
+			visible = append(visible, field)
+		}
+	}
+	return
+}
+
+// the sub-commands sorted by the order tag, keep the declaration order
+// when the order is the same
+func (stropt *StrOpt) subs() (fields []Field) {
+	fields = append(fields, stropt.sub_list...)
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return order(fields[i]) < order(fields[j])
+	})
+	return
+}
+
+// the order of the sub-command, default is 0
+func order(field Field) (n int) {
+	if v, ok := field.GetTag().Lookup(KEY_ORDER); ok {
+		// already validated when set the sub-command
+		n, _ = strconv.Atoi(v)
+	}
+	return
+}
+
+// group the sub-commands by the category, the uncategorized sub-commands
+// are always listed first and others by the first appearance.
+func categorize(fields []Field) (categories []string, groups map[string][]Field) {
+	groups = map[string][]Field{}
+
+	for _, field := range fields {
+		category, _ := field.GetTag().Lookup(KEY_CATEGORY)
+		if _, ok := groups[category]; !ok {
+			categories = append(categories, category)
 		}
+		groups[category] = append(groups[category], field)
 	}
 
+	// the uncategorized sub-commands always be the first
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i] == "" && categories[j] != ""
+	})
+	return
+}
+
+// the alias names of the field
+func aliases(field Field) (names []string) {
+	if v, ok := field.GetTag().Lookup(KEY_ALIAS); ok {
+		names = strings.Fields(v)
+	}
 	return
 }
 
@@ -399,14 +905,19 @@ func (stropt *StrOpt) setField(value reflect.Value, typ reflect.StructField) (fi
 				}
 
 				shadow := reflect.New(typ.Type.Elem())
+				initial := reflect.New(typ.Type).Elem()
+				initial.Set(value)
 
 				sub := &StrOpt{
 					Value:        value,
 					shadow:       shadow,
+					initial:      initial,
 					Tracer:       stropt.Tracer,
+					parent:       stropt,
 					name:         name,
 					tag:          typ.Tag,
 					named_fields: map[string]Field{},
+					sub_fields:   map[string]Field{},
 				}
 
 				if err = sub.prologue(shadow.Elem(), typ.Type.Elem()); err != nil {
@@ -475,11 +986,23 @@ func (stropt *StrOpt) setOption(field Field) (err error) {
 		stropt.named_fields[shortcut] = field
 	}
 
+	for _, alias := range aliases(field) {
+		if _, ok := stropt.named_fields[alias]; ok {
+			err = fmt.Errorf("duplicate field alias: %v", alias)
+			return
+		}
+		stropt.named_fields[alias] = field
+	}
+
 	if _default, ok := field.GetTag().Lookup(KEY_DEFAULT); ok {
 		// set the default value before parse
-		_, err = field.Parse(_default)
+		if _, err = field.Parse(_default); err != nil {
+			return
+		}
 	}
 
+	// the default value is restored by Reset
+	err = field.Snapshot()
 	return
 }
 
@@ -489,8 +1012,13 @@ func (stropt *StrOpt) setArgument(field Field) (err error) {
 
 	if _default, ok := field.GetTag().Lookup(KEY_DEFAULT); ok {
 		// set the default value before parse
-		_, err = field.Parse(_default)
+		if _, err = field.Parse(_default); err != nil {
+			return
+		}
 	}
+
+	// the default value is restored by Reset
+	err = field.Snapshot()
 	return
 }
 
@@ -501,12 +1029,28 @@ func (stropt *StrOpt) setSub(field Field) (err error) {
 		err = fmt.Errorf("duplicate sub-command: %v", name)
 		return
 	}
+	if v, ok := field.GetTag().Lookup(KEY_ORDER); ok {
+		if _, err = strconv.Atoi(v); err != nil {
+			err = fmt.Errorf("invalid order of sub-command %v: %v", name, v)
+			return
+		}
+	}
+
 	stropt.sub_fields[name] = field
+	stropt.sub_list = append(stropt.sub_list, field)
+
+	for _, alias := range aliases(field) {
+		if _, ok := stropt.sub_fields[alias]; ok {
+			err = fmt.Errorf("duplicate sub-command alias: %v", alias)
+			return
+		}
+		stropt.sub_fields[alias] = field
+	}
 	return
 }
 
-// show the field description
-func (stropt *StrOpt) description(field Field, sub bool) (desc string) {
+// show the field description, return the option column and the description
+func (stropt *StrOpt) description(field Field, sub bool) (option, desc string) {
 	name := field.GetName()
 	shortcut := field.GetShortcut()
 
@@ -535,32 +1079,57 @@ func (stropt *StrOpt) description(field Field, sub bool) (desc string) {
 		help = fmt.Sprintf("%v [%v]", help, strings.Join(choice, " "))
 	}
 
-	desc = fmt.Sprintf("%3v %v %v", shortcut, name, field.Hint())
-	switch _default := field.Default(); _default {
+	if names := aliases(field); len(names) > 0 {
+		for idx, alias := range names {
+			switch {
+			case sub:
+			case len([]rune(alias)) == 1:
+				names[idx] = fmt.Sprintf("-%v", alias)
+			default:
+				names[idx] = fmt.Sprintf("--%v", alias)
+			}
+		}
+
+		// append the alias
+		help = fmt.Sprintf("%v [alias: %v]", help, strings.Join(names, " "))
+	}
+
+	option = fmt.Sprintf("%3v %v %v", shortcut, name, field.Hint())
+	switch _default := stropt.defaultValue(field); _default {
 	case "":
-		desc = fmt.Sprintf("    %-22v %v", desc, help)
+		desc = help
 	default:
-		desc = fmt.Sprintf("    %-22v %v [default: %v]", desc, help, _default)
+		desc = fmt.Sprintf("%v [default: %v]", help, _default)
 	}
 
 	idx := sort.SearchStrings(attrs, KEY_ATTR_REQUIRED)
 	if idx >= 0 && idx < len(attrs) && attrs[idx] == KEY_ATTR_REQUIRED {
 		// set option is required
-		desc = fmt.Sprintf("%v (required)", desc)
+		desc = fmt.Sprintf("%v %v", desc, usage_required)
 	}
 
+	if _, ok := field.GetTag().Lookup(KEY_DEPRECATED); ok {
+		// set option is deprecated
+		desc = fmt.Sprintf("%v (deprecated)", desc)
+	}
+
+	option = strings.TrimRight(option, " ")
 	desc = strings.TrimRight(desc, " ")
 	return
 }
 
 // show the version info
 func (stropt *StrOpt) Version_(_stropt *StrOpt, _field Field) (err error) {
-	switch stropt.version {
-	case "":
+	switch {
+	case stropt.version == "":
 		err = ERR_CALLBACK_NOT_IMPLEMENTED
+	case stropt.isNoExit():
+		err = &TextError{Err: ERR_VERSION, Text: stropt.version}
 	default:
-		os.Stdout.WriteString(stropt.version)
-		os.Exit(0)
+		stropt.getStdout().Write([]byte(stropt.version)) //nolint
+		stropt.exitWith(EXIT_SUCCESS)
+		// stop the parse even the exit function returns
+		err = ERR_VERSION
 	}
 	return
 }
@@ -568,3 +1137,93 @@ func (stropt *StrOpt) Version_(_stropt *StrOpt, _field Field) (err error) {
 func (stropt *StrOpt) Version(ver string) {
 	stropt.version = ver
 }
+
+// override the writer of the normal output, default is os.Stdout
+func (stropt *StrOpt) Stdout(w io.Writer) {
+	stropt.stdout = w
+}
+
+// override the writer of the error output, default is os.Stderr
+func (stropt *StrOpt) Stderr(w io.Writer) {
+	stropt.stderr = w
+}
+
+// override the function called when exit, default is os.Exit
+func (stropt *StrOpt) ExitFunc(exit func(code int)) {
+	stropt.exit = exit
+}
+
+// set the no-exit mode, the built-in callback like Help_ and Version_ return
+// the sentinel error ERR_HELP and ERR_VERSION with the rendered text instead
+// of exit.
+func (stropt *StrOpt) NoExit(no_exit bool) {
+	stropt.no_exit = no_exit
+}
+
+// allow multiple chained sub-commands in the single invocation, like
+// `app build test deploy`, and each sub-command stops parse when meets the
+// next sub-command or the option it cannot resolve, which is passed back to
+// the parent command.
+func (stropt *StrOpt) Chain(chain bool) {
+	stropt.chain = chain
+}
+
+// check the chain mode, inherit from the parent
+func (stropt *StrOpt) isChain() (chain bool) {
+	chain = stropt.chain || (stropt.parent != nil && stropt.parent.isChain())
+	return
+}
+
+// the sub-command in the chain mode, which returns the unknown option and
+// the next sub-command to the parent. The persistent options of the parent
+// are always resolvable in the sub-command.
+func (stropt *StrOpt) isChained() (chained bool) {
+	chained = stropt.parent != nil && stropt.isChain()
+	return
+}
+
+// check the no-exit mode, inherit from the parent
+func (stropt *StrOpt) isNoExit() (no_exit bool) {
+	no_exit = stropt.no_exit || (stropt.parent != nil && stropt.parent.isNoExit())
+	return
+}
+
+// the writer of the normal output, inherit from the parent
+func (stropt *StrOpt) getStdout() (w io.Writer) {
+	switch {
+	case stropt.stdout != nil:
+		w = stropt.stdout
+	case stropt.parent != nil:
+		w = stropt.parent.getStdout()
+	default:
+		w = os.Stdout
+	}
+	return
+}
+
+// the writer of the error output, inherit from the parent
+func (stropt *StrOpt) getStderr() (w io.Writer) {
+	switch {
+	case stropt.stderr != nil:
+		w = stropt.stderr
+	case stropt.parent != nil:
+		w = stropt.parent.getStderr()
+	default:
+		w = os.Stderr
+	}
+	return
+}
+
+// exit with the code, inherit the exit function from the parent
+func (stropt *StrOpt) exitWith(code int) {
+	stropt.Infof("exit with code %v", code)
+
+	switch {
+	case stropt.exit != nil:
+		stropt.exit(code)
+	case stropt.parent != nil:
+		stropt.parent.exitWith(code)
+	default:
+		os.Exit(code)
+	}
+}
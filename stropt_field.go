@@ -39,6 +39,14 @@ func (stropt *StrOpt) Default() (def string) {
 	return
 }
 
+// save the current sub-command as the initial value
+func (stropt *StrOpt) Snapshot() (err error) {
+	if stropt.initial.IsValid() {
+		stropt.initial.Set(stropt.Value)
+	}
+	return
+}
+
 // the hint of the sub-command, should empty
 func (stropt *StrOpt) Hint() (hint string) {
 	return
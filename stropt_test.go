@@ -1,9 +1,12 @@
 package stropt
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -289,7 +292,7 @@ func TestParseArgument(t *testing.T) {
 		t.Errorf("parse message fail: %#v", foo.Message)
 	}
 
-	if _, err := parser.Parse("123"); err != nil {
+	if _, err := parser.Parse("message", "123"); err != nil {
 		// cannot parse arguments
 		t.Errorf("cannot parse argument: %v", err)
 	} else if foo.Amount == nil || *foo.Amount != 123 {
@@ -297,7 +300,7 @@ func TestParseArgument(t *testing.T) {
 		t.Errorf("parse amount fail: %#v", foo.Amount)
 	}
 
-	if _, err := parser.Parse("ccc"); err == nil {
+	if _, err := parser.Parse("message", "123", "ccc"); err == nil {
 		// expect cannot parse argument
 		t.Errorf("expect cannot parse extra argument")
 	}
@@ -315,3 +318,447 @@ func TestRequired(t *testing.T) {
 		t.Errorf("cannot setup the required field: %v", err)
 	}
 }
+
+func TestExitEnvironment(t *testing.T) {
+	foo := &Foo{}
+	parser := MustNew(foo)
+	parser.Version("foo (test)")
+
+	code := -1
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	parser.Stdout(stdout)
+	parser.Stderr(stderr)
+	parser.ExitFunc(func(c int) { code = c })
+
+	if _, err := parser.Parse("-h", "--flip"); !errors.Is(err, ERR_HELP) || foo.Flip {
+		t.Errorf("expect stop parse after help even the exit returns: %v", err)
+	} else if code != EXIT_FAILURE || !strings.HasPrefix(stderr.String(), "usage: foo") {
+		t.Errorf("expect show help on stderr (%v): %#v", code, stderr.String())
+	}
+
+	if _, err := parser.Parse("-v"); !errors.Is(err, ERR_VERSION) {
+		t.Errorf("expect ERR_VERSION even the exit returns: %v", err)
+	} else if code != EXIT_SUCCESS || stdout.String() != "foo (test)" {
+		t.Errorf("expect show version on stdout (%v): %#v", code, stdout.String())
+	}
+
+	if sub := parser.sub_fields["subc"].(*StrOpt); sub.getStderr() != stderr {
+		t.Errorf("expect sub-command inherit stderr")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", NewExitError(fmt.Errorf("failure"), 3))
+
+	if code := ExitCode(err, EXIT_FAILURE); code != 3 {
+		t.Errorf("expect exit code 3: %v", code)
+	} else if code := ExitCode(fmt.Errorf("failure"), EXIT_USAGE); code != EXIT_USAGE {
+		t.Errorf("expect fallback exit code: %v", code)
+	} else if code := ExitCode(nil, EXIT_USAGE); code != EXIT_SUCCESS {
+		t.Errorf("expect success exit code: %v", code)
+	}
+}
+
+func TestNoExit(t *testing.T) {
+	foo := &Foo{}
+	parser := MustNew(foo)
+	parser.Version("foo (test)")
+	parser.NoExit(true)
+	parser.ExitFunc(func(code int) { t.Fatalf("should not exit: %v", code) })
+
+	var text_err *TextError
+
+	_, err := parser.Parse("--flip", "-h", "--flip")
+	switch {
+	case !errors.Is(err, ERR_HELP):
+		t.Errorf("expect ERR_HELP: %v", err)
+	case !errors.As(err, &text_err) || !strings.HasPrefix(text_err.Text, "usage: foo"):
+		t.Errorf("expect help message: %v", err)
+	case !foo.Flip:
+		t.Errorf("expect stop parse after -h")
+	}
+
+	_, err = parser.Parse("-v")
+	switch {
+	case !errors.Is(err, ERR_VERSION):
+		t.Errorf("expect ERR_VERSION: %v", err)
+	case !errors.As(err, &text_err) || text_err.Text != "foo (test)":
+		t.Errorf("expect version info: %v", err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	foo := &Foo{Price: 12.34}
+	parser := MustNew(foo)
+
+	if _, err := parser.Parse("--age", "12", "-p", "1", "--flip", "msg", "subc", "--flip", "10"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if foo.Sub == nil || foo.Sub.Age == nil || *foo.Sub.Age != 10 || !foo.Sub.Flip {
+		t.Fatalf("cannot parse sub-command: %#v", foo.Sub)
+	}
+
+	if _, err := parser.Parse("msg"); err != nil {
+		t.Fatalf("cannot parse repeatedly: %v", err)
+	}
+
+	switch {
+	case foo.Age != 21:
+		t.Errorf("expect reset to default 21: %v", foo.Age)
+	case foo.Price != 12.34:
+		t.Errorf("expect reset to initial 12.34: %v", foo.Price)
+	case foo.Flip:
+		t.Errorf("expect reset flip")
+	case foo.Message == nil || *foo.Message != "msg":
+		t.Errorf("expect parse the first argument: %#v", foo.Message)
+	case foo.Amount != nil:
+		t.Errorf("expect reset argument: %#v", foo.Amount)
+	case foo.Sub != nil:
+		t.Errorf("expect reset sub-command: %#v", foo.Sub)
+	}
+
+	if _, err := parser.Parse("subc", "11"); err != nil {
+		t.Fatalf("cannot parse sub-command repeatedly: %v", err)
+	} else if foo.Sub == nil || *foo.Sub.Age != 11 || foo.Sub.Flip {
+		t.Errorf("expect reset the sub-command shadow: %#v", foo.Sub)
+	}
+
+	// the value set by the caller is kept as the initial value
+	foo.Price = 56.78
+	foo.Number = 7
+	if _, err := parser.Parse("--age", "12"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if foo.Price != 56.78 || foo.Number != 7 {
+		t.Errorf("expect keep the value set by caller: %#v", foo)
+	} else if _, err := parser.Parse(); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if foo.Age != 21 || foo.Price != 56.78 || foo.Number != 7 {
+		t.Errorf("expect reset to the value set by caller: %#v", foo)
+	}
+
+	foo = &Foo{}
+	parser = MustNew(foo)
+	foo.Name = "caller"
+	if _, err := parser.Parse(); err != nil || foo.Name != "caller" {
+		t.Errorf("expect keep the value set after New (%v): %v", err, foo.Name)
+	}
+}
+
+type AliasSub struct {
+	Force bool `shortcut:"f" alias:"yes"`
+}
+
+type Alias struct {
+	Output string `shortcut:"o" alias:"out O" desc:"the output"`
+	Debug  bool   `attr:"hidden"`
+
+	*AliasSub `name:"delete" alias:"rm remove" desc:"delete the resource"`
+	Legacy    *AliasSub `attr:"hidden"`
+}
+
+func TestAliasAndHidden(t *testing.T) {
+	alias := &Alias{}
+	parser := MustNew(alias)
+
+	if _, err := parser.Parse("--out", "a", "--debug", "rm", "--yes"); err != nil {
+		t.Fatalf("cannot parse alias: %v", err)
+	} else if alias.Output != "a" || !alias.Debug || alias.AliasSub == nil || !alias.AliasSub.Force {
+		t.Errorf("cannot set by alias: %#v", alias)
+	} else if _, err := parser.Parse("-O", "b", "remove"); err != nil || alias.Output != "b" || alias.AliasSub == nil {
+		t.Errorf("cannot set by alias (%v): %#v", err, alias)
+	} else if _, err := parser.Parse("legacy", "-f"); err != nil || alias.Legacy == nil {
+		t.Errorf("expect hidden sub-command works (%v): %#v", err, alias)
+	}
+
+	buff := &bytes.Buffer{}
+	parser.Usage(buff)
+	expect := strings.Join([]string{
+		"usage: alias [OPTION]",
+		"",
+		"options:",
+		"     -o --output STR       the output [alias: --out -O]",
+		"",
+		"sub-commands:",
+		"        delete             delete the resource [alias: rm remove]",
+		"",
+	}, "\n")
+	if buff.String() != expect {
+		t.Errorf("expect usage:\n%v\nbut got:\n%v", expect, buff.String())
+	}
+}
+
+type Deprecated struct {
+	Output string `shortcut:"o" desc:"the output"`
+	Out    string `deprecated:"use --output instead" forward:"output" desc:"the output"`
+	Quiet  bool   `deprecated:""`
+}
+
+func TestDeprecated(t *testing.T) {
+	deprecated := &Deprecated{}
+	parser := MustNew(deprecated)
+
+	stderr := &bytes.Buffer{}
+	parser.Stderr(stderr)
+
+	if _, err := parser.Parse("--out", "a", "--out", "b", "--quiet"); err != nil {
+		t.Fatalf("cannot parse deprecated option: %v", err)
+	} else if deprecated.Out != "b" || deprecated.Output != "b" || !deprecated.Quiet {
+		t.Errorf("expect forward to --output: %#v", deprecated)
+	}
+
+	expect := "warning: --out is deprecated, use --output instead\nwarning: --quiet is deprecated\n"
+	if stderr.String() != expect {
+		t.Errorf("expect warning once:\n%v\nbut got:\n%v", expect, stderr.String())
+	}
+
+	buff := &bytes.Buffer{}
+	parser.Usage(buff)
+	if !strings.Contains(buff.String(), "--out STR          the output (deprecated)") {
+		t.Errorf("expect mark deprecated in usage:\n%v", buff.String())
+	}
+}
+
+type Forwarded struct {
+	Level   string  `choice:"info debug" callback:"Level_"`
+	Verbose string  `deprecated:"" forward:"level"`
+	Key     *string `attr:"secret" deprecated:"pass by --token"`
+
+	levels []string
+}
+
+func (forwarded *Forwarded) Level_(level string) (err error) {
+	forwarded.levels = append(forwarded.levels, level)
+	return
+}
+
+func TestDeprecatedForward(t *testing.T) {
+	forwarded := &Forwarded{}
+	parser := MustNew(forwarded)
+
+	stderr := &bytes.Buffer{}
+	parser.Stderr(stderr)
+
+	switch _, err := parser.Parse("--verbose", "debug", "s3cret"); {
+	case err != nil:
+		t.Fatalf("cannot parse deprecated field: %v", err)
+	case forwarded.Level != "debug" || strings.Join(forwarded.levels, " ") != "debug":
+		t.Errorf("expect forward to --level with callback: %#v", forwarded)
+	case stderr.String() != "warning: --verbose is deprecated\nwarning: key is deprecated, pass by --token\n":
+		t.Errorf("expect warning by the field name: %q", stderr.String())
+	}
+
+	if _, err := parser.Parse("--verbose", "trace"); err == nil {
+		t.Errorf("expect check the choice of the forward target")
+	}
+
+	if _, err := New(&struct {
+		Old string `forward:"new"`
+	}{}); err == nil || !strings.Contains(err.Error(), "forward") {
+		t.Errorf("expect the forward target not found: %v", err)
+	}
+}
+
+type Ordered struct {
+	Zeta  *Sub `desc:"the zeta command"`
+	Alpha *Sub `desc:"the alpha command" order:"-1"`
+	Image *Sub `desc:"manage images" category:"management commands"`
+	Build *Sub `desc:"build an image"`
+	Net   *Sub `desc:"manage networks" category:"management commands" order:"1"`
+}
+
+func TestOrderedSubCommand(t *testing.T) {
+	parser := MustNew(&Ordered{})
+
+	expect := strings.Join([]string{
+		"usage: ordered",
+		"",
+		"sub-commands:",
+		"        alpha              the alpha command",
+		"        zeta               the zeta command",
+		"        build              build an image",
+		"",
+		"management commands:",
+		"        image              manage images",
+		"        net                manage networks",
+		"",
+	}, "\n")
+
+	for idx := 0; idx < 8; idx++ {
+		buff := &bytes.Buffer{}
+		parser.Usage(buff)
+
+		if buff.String() != expect {
+			t.Fatalf("expect usage:\n%v\nbut got:\n%v", expect, buff.String())
+		}
+	}
+}
+
+type NestedSubSub struct {
+	Force bool `desc:"force run"`
+}
+
+type NestedSub struct {
+	Dry bool `desc:"dry run"`
+
+	*NestedSubSub `name:"subsub"`
+}
+
+type NestedApp struct {
+	Verbose bool `desc:"verbose mode"`
+
+	*NestedSub `name:"sub"`
+}
+
+func TestNestedUsage(t *testing.T) {
+	parser := MustNew(&NestedApp{})
+	parser.Name("app")
+	parser.NoExit(true)
+
+	var text_err *TextError
+
+	_, err := parser.Parse("help", "sub", "subsub")
+	switch {
+	case !errors.Is(err, ERR_HELP) || !errors.As(err, &text_err):
+		t.Fatalf("expect ERR_HELP: %v", err)
+	case !strings.HasPrefix(text_err.Text, "usage: app [GLOBAL OPTIONS] sub [GLOBAL OPTIONS] subsub [OPTION]\n"):
+		t.Errorf("expect the full usage:\n%v", text_err.Text)
+	}
+
+	if _, err := parser.Parse("help", "unknown"); err == nil || errors.Is(err, ERR_HELP) {
+		t.Errorf("expect unknown sub-command: %v", err)
+	}
+
+	search := &Search{}
+	parser = MustNew(search)
+	parser.NoExit(true)
+	if _, err := parser.Parse("help"); err != nil || search.Term == nil || *search.Term != "help" {
+		t.Errorf("expect pass the literal help to the argument (%v): %#v", err, search.Term)
+	} else if _, err := parser.Parse("term", "help"); !errors.Is(err, ERR_HELP) {
+		t.Errorf("expect the help command after arguments filled: %v", err)
+	}
+}
+
+type Search struct {
+	Term *string `desc:"the search term"`
+
+	*NestedSub `name:"sub"`
+}
+
+type PersistentSub struct {
+	Name string `shortcut:"n" desc:"the name"`
+}
+
+type Persistent struct {
+	Verbose bool   `shortcut:"v" attr:"persistent" desc:"verbose mode"`
+	Config  string `attr:"persistent" desc:"the config file"`
+	Local   bool   `desc:"the local option"`
+
+	*PersistentSub `name:"sub"`
+}
+
+func TestPersistent(t *testing.T) {
+	persistent := &Persistent{}
+	parser := MustNew(persistent)
+
+	if _, err := parser.Parse("sub", "-v", "-n", "foo", "--config", "a.yml"); err != nil {
+		t.Fatalf("cannot parse persistent option: %v", err)
+	} else if !persistent.Verbose || persistent.Config != "a.yml" || persistent.PersistentSub == nil {
+		t.Errorf("expect set persistent option in the parent: %#v", persistent)
+	} else if _, err := parser.Parse("sub", "--local"); err == nil {
+		t.Errorf("expect non-persistent option not found in sub-command")
+	} else if _, err := parser.Parse("sub", "--name", "x", "extra"); err == nil {
+		t.Errorf("expect reject the leftover argument in sub-command")
+	}
+
+	buff := &bytes.Buffer{}
+	parser.sub_fields["sub"].(*StrOpt).Usage(buff)
+	expect := strings.Join([]string{
+		"usage: persistent [GLOBAL OPTIONS] sub [OPTION]",
+		"",
+		"options:",
+		"     -n --name STR         the name",
+		"",
+		"global options:",
+		"     -v --verbose          verbose mode",
+		"        --config STR       the config file",
+		"",
+	}, "\n")
+	if buff.String() != expect {
+		t.Errorf("expect usage:\n%v\nbut got:\n%v", expect, buff.String())
+	}
+}
+
+type RemoteAdd struct {
+	Name *string
+}
+
+type Remote struct {
+	Add    *RemoteAdd
+	Remove *RemoteAdd
+}
+
+type Git struct {
+	Verbose bool
+
+	*Remote
+}
+
+func TestSelected(t *testing.T) {
+	git := &Git{}
+	parser := MustNew(git)
+
+	if _, err := parser.Parse("--verbose"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if path, commands := parser.Selected(); path != "" || len(commands) != 0 {
+		t.Errorf("expect no sub-command selected: %v %v", path, commands)
+	}
+
+	if _, err := parser.Parse("remote", "add"); err != nil {
+		t.Fatalf("cannot parse: %v", err)
+	} else if git.Remote == nil || git.Remote.Add == nil || git.Remote.Remove != nil {
+		t.Errorf("expect materialize the selected sub-command: %#v", git.Remote)
+	}
+
+	switch path, commands := parser.Selected(); {
+	case path != "remote add":
+		t.Errorf("expect select remote add: %v", path)
+	case len(commands) != 2 || commands[1].GetName() != "add":
+		t.Errorf("expect the StrOpt of the selected sub-command: %v", commands)
+	}
+}
+
+func TestParseCommandLine(t *testing.T) {
+	foo := &Foo{}
+	parser := MustNew(foo)
+
+	if n, err := parser.ParseString(`--age 12 --flip 'hello world'`); err != nil || n != 4 {
+		t.Fatalf("cannot parse string (%v): %v", n, err)
+	} else if foo.Age != 12 || !foo.Flip || foo.Message == nil || *foo.Message != "hello world" {
+		t.Errorf("cannot parse string: %#v", foo)
+	}
+
+	if _, err := parser.ParseString(`--flip "it's \"quoted\"" `); err != nil || *foo.Message != `it's "quoted"` {
+		t.Errorf("cannot parse double quoted string (%v): %#v", err, foo.Message)
+	} else if _, err := parser.ParseString(`a\ b\$HOME`); err != nil || *foo.Message != `a b$HOME` {
+		t.Errorf("cannot parse escaped string (%v): %#v", err, foo.Message)
+	}
+
+	var syntax_err *SyntaxError
+	if _, err := parser.ParseString(`--flip "unterminated`); !errors.As(err, &syntax_err) || syntax_err.Column != 8 {
+		t.Errorf("expect quoting error at column 8: %v", err)
+	} else if _, err := parser.ParseString(`msg \`); !errors.As(err, &syntax_err) || syntax_err.Column != 5 {
+		t.Errorf("expect trailing backslash at column 5: %v", err)
+	}
+
+	if _, err := parser.ParseString("--flip\n  'unterminated"); !errors.As(err, &syntax_err) || syntax_err.Line != 2 || syntax_err.Column != 3 {
+		t.Errorf("expect quoting error at line 2, column 3: %v", err)
+	} else if _, err := parser.ParseString("--flip \"a\\\nb\" 'x"); !errors.As(err, &syntax_err) || syntax_err.Line != 2 || syntax_err.Column != 4 {
+		t.Errorf("expect quoting error after the escaped new line at line 2, column 4: %v", err)
+	}
+
+	if n, err := parser.ParseString("--flip \"a\\\nb#c\""); err != nil || n != 2 || *foo.Message != "ab#c" {
+		t.Errorf("expect join the escaped new line in double quote (%v, %v): %#v", n, err, foo.Message)
+	} else if _, err := parser.ParseString("--flip a#b"); err != nil || *foo.Message != "a#b" {
+		t.Errorf("expect '#' not the comment (%v): %#v", err, foo.Message)
+	}
+}
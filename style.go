@@ -0,0 +1,112 @@
+package stropt
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// the ANSI SGR parameters of each element in the usage and error message,
+// like "1" for bold and "31" for red. Empty for no style.
+type Style struct {
+	// the section heading, like "options:"
+	Heading string
+	// the option and sub-command name
+	Option string
+	// the type hint of the option
+	Hint string
+	// the required marker
+	Required string
+	// the error prefix
+	Error string
+}
+
+var (
+	// the default style used when the color is enabled
+	DEFAULT_STYLE = Style{
+		Heading:  "1",
+		Option:   "36",
+		Hint:     "33",
+		Required: "31",
+		Error:    "1;31",
+	}
+)
+
+// apply the SGR parameter on the text, return the text as-is when the
+// parameter or the text is empty.
+func (style Style) Apply(sgr, text string) string {
+	if sgr == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + sgr + "m" + text + "\x1b[0m"
+}
+
+// set the style of the usage and error message, inherited by sub-commands
+func (stropt *StrOpt) Theme(style Style) {
+	stropt.style = &style
+}
+
+// the style used to write on the writer, the color is enabled only when the
+// writer is the terminal, and respect the NO_COLOR and FORCE_COLOR.
+func (stropt *StrOpt) getStyle(w io.Writer) (style Style) {
+	if !colorEnabled(w) {
+		// no color
+		return
+	}
+
+	style = DEFAULT_STYLE
+	for parser := stropt; parser != nil; parser = parser.parent {
+		if parser.style != nil {
+			// the style inherit from the parent
+			style = *parser.style
+			break
+		}
+	}
+	return
+}
+
+// check the color is enabled on the writer
+func colorEnabled(w io.Writer) (enabled bool) {
+	switch force := os.Getenv("FORCE_COLOR"); {
+	case os.Getenv("NO_COLOR") != "":
+		// https://no-color.org/
+	case force != "" && force != "0" && force != "false":
+		enabled = true
+	default:
+		if file, ok := w.(*os.File); ok {
+			enabled = isTerminal(file.Fd())
+		}
+	}
+	return
+}
+
+// style the option column, the option names and the type hint
+func styleOption(style Style, option, hint string) (styled string) {
+	names := option
+	if hint != "" && strings.HasSuffix(option, hint) {
+		names = strings.TrimSuffix(option, hint)
+		hint = style.Apply(style.Hint, hint)
+	} else {
+		hint = ""
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(names, " ") {
+		tokens = append(tokens, style.Apply(style.Option, token))
+	}
+
+	styled = strings.Join(tokens, " ") + hint
+	return
+}
+
+// style the required marker appended by the parser, which is the last one
+// in the description lines and never the text in the desc tag
+func styleRequired(style Style, descs []string) {
+	for idx := len(descs) - 1; idx >= 0; idx-- {
+		if pos := strings.LastIndex(descs[idx], usage_required); pos >= 0 {
+			desc := descs[idx]
+			descs[idx] = desc[:pos] + style.Apply(style.Required, usage_required) + desc[pos+len(usage_required):]
+			return
+		}
+	}
+}
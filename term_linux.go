@@ -0,0 +1,11 @@
+package stropt
+
+import (
+	"syscall"
+)
+
+// the ioctl request to get and set the terminal attributes
+const (
+	ioctl_get_termios = syscall.TCGETS
+	ioctl_set_termios = syscall.TCSETS
+)
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package stropt
+
+import (
+	"fmt"
+)
+
+// the width of the terminal, not support on this platform
+func ttyWidth(fd uintptr) (width int) {
+	return
+}
+
+// check the file descriptor is the terminal, not support on this platform
+func isTerminal(fd uintptr) (ok bool) {
+	return
+}
+
+// disable the echo of the terminal, not support on this platform
+func disableEcho(fd uintptr) (restore func(), err error) {
+	err = fmt.Errorf("not support disable echo")
+	return
+}
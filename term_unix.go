@@ -0,0 +1,54 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package stropt
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// the width of the terminal, return 0 if not the terminal
+func ttyWidth(fd uintptr) (width int) {
+	ws := struct {
+		Row    uint16
+		Col    uint16
+		Xpixel uint16
+		Ypixel uint16
+	}{}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno == 0 {
+		width = int(ws.Col)
+	}
+	return
+}
+
+// check the file descriptor is the terminal, even the width is unknown
+func isTerminal(fd uintptr) (ok bool) {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctl_get_termios, uintptr(unsafe.Pointer(&termios)))
+	ok = errno == 0
+	return
+}
+
+// disable the echo of the terminal, return the function to restore
+func disableEcho(fd uintptr) (restore func(), err error) {
+	var termios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctl_get_termios, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		err = errno
+		return
+	}
+
+	origin := termios
+	termios.Lflag &^= syscall.ECHO
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctl_set_termios, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		err = errno
+		return
+	}
+
+	restore = func() {
+		syscall.Syscall(syscall.SYS_IOCTL, fd, ioctl_set_termios, uintptr(unsafe.Pointer(&origin))) //nolint
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package stropt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the syntax error when tokenize the command-line, with the position of the
+// problematic character.
+type SyntaxError struct {
+	// the reason of the error
+	Msg string
+	// the 1-based line and column (in runes) of the error
+	Line   int
+	Column int
+}
+
+// the error message with the position
+func (syntax_err *SyntaxError) Error() (msg string) {
+	msg = fmt.Sprintf("%v at line %d, column %d", syntax_err.Msg, syntax_err.Line, syntax_err.Column)
+	return
+}
+
+// split the text into the tokens by the POSIX shell quoting rules: the single
+// quote keeps everything literal, the double quote only escapes \ " $ ` and
+// the new line, and the backslash escapes the next character outside quote.
+// No variable, command or glob expansion is made. The word starts with '#'
+// is the comment to the end of the line when comment is enabled.
+func tokenize(text string, comment bool) (tokens []string, err error) {
+	var word strings.Builder
+
+	// the state of the current word
+	in_word := false
+	// the quote and the position of the opening quote
+	var quote rune
+	quote_line, quote_column := 0, 0
+
+	line, column := 1, 0
+	runes := []rune(text)
+	for idx := 0; idx < len(runes); idx++ {
+		r := runes[idx]
+
+		column++
+
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && idx+1 < len(runes) && strings.ContainsRune("\\\"$`\n", runes[idx+1]):
+				idx++
+				column++
+				if runes[idx] != '\n' {
+					word.WriteRune(runes[idx])
+				}
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, quote_line, quote_column = r, line, column
+			in_word = true
+		case r == '\\':
+			if idx+1 >= len(runes) {
+				err = &SyntaxError{Msg: "trailing backslash", Line: line, Column: column}
+				return
+			}
+
+			idx++
+			column++
+			if runes[idx] != '\n' {
+				word.WriteRune(runes[idx])
+				in_word = true
+			}
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			if in_word {
+				tokens = append(tokens, word.String())
+				word.Reset()
+				in_word = false
+			}
+		case r == '#' && comment && !in_word:
+			// skip to the end of the line
+			for idx+1 < len(runes) && runes[idx+1] != '\n' {
+				idx++
+			}
+		default:
+			word.WriteRune(r)
+			in_word = true
+		}
+
+		if runes[idx] == '\n' {
+			line, column = line+1, 0
+		}
+	}
+
+	switch quote {
+	case '\'':
+		err = &SyntaxError{Msg: "unterminated single quote", Line: quote_line, Column: quote_column}
+		return
+	case '"':
+		err = &SyntaxError{Msg: "unterminated double quote", Line: quote_line, Column: quote_column}
+		return
+	}
+
+	if in_word {
+		tokens = append(tokens, word.String())
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package stropt
+
+import (
+	"fmt"
+)
+
+// the trace message of the StrOpt, also passed to the trace hook
+func (stropt *StrOpt) Tracef(format string, args ...interface{}) {
+	stropt.Tracer.Tracef(format, args...)
+	stropt.hook(format, args...)
+}
+
+// the debug message of the StrOpt, also passed to the trace hook
+func (stropt *StrOpt) Debugf(format string, args ...interface{}) {
+	stropt.Tracer.Debugf(format, args...)
+	stropt.hook(format, args...)
+}
+
+// the info message of the StrOpt, also passed to the trace hook
+func (stropt *StrOpt) Infof(format string, args ...interface{}) {
+	stropt.Tracer.Infof(format, args...)
+	stropt.hook(format, args...)
+}
+
+// the warning message of the StrOpt, also passed to the trace hook
+func (stropt *StrOpt) Warnf(format string, args ...interface{}) {
+	stropt.Tracer.Warnf(format, args...)
+	stropt.hook(format, args...)
+}
+
+// pass the formatted message to the trace hook of the root command, which
+// is used to inspect the trace
+func (stropt *StrOpt) hook(format string, args ...interface{}) {
+	root := stropt
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	if root.trace_hook != nil {
+		root.trace_hook(fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,371 @@
+package stropt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+const (
+	// the indent of the row in usage
+	usage_indent = 4
+	// the minimal and maximal width of the option column in usage
+	usage_min_column = 22
+	usage_max_column = 40
+	// the minimal width of the wrapped description
+	usage_min_wrap = 20
+	// the marker of the required field in the description
+	usage_required = "(required)"
+)
+
+// the default usage template, may override by StrOpt.Template
+var USAGE_TEMPLATE = `
+{{- with .Prolog }}{{ . }}
+
+{{ end -}}
+{{ heading $ "usage:" }} {{ .Path }}{{ if .Options }} [OPTION]{{ end }}{{ if .Arguments }} [ARGS] ...{{ end }}
+{{ if .Options }}
+{{ heading $ "options:" }}
+{{ range .Options }}{{ row $ . }}
+{{ end }}{{ end }}
+{{- if .GlobalOptions }}
+{{ heading $ "global options:" }}
+{{ range .GlobalOptions }}{{ row $ . }}
+{{ end }}{{ end }}
+{{- if .Arguments }}
+{{ heading $ "arguments:" }}
+{{ range .Arguments }}{{ row $ . }}
+{{ end }}{{ end }}
+{{- range .Categories }}
+{{ with .Name }}{{ heading $ (printf "%v:" .) }}{{ else }}{{ heading $ "sub-commands:" }}{{ end }}
+{{ range .Commands }}{{ row $ . }}
+{{ end }}{{ end }}
+{{- with .Example }}
+{{ heading $ "examples:" }}
+{{ indent 4 . }}
+{{ end }}
+{{- with .Epilog }}
+{{ . }}
+{{ end }}`
+
+// the data model fed to the usage template
+type UsageData struct {
+	// the name of the command
+	Name string
+	// the full command path with the parent commands
+	Path string
+
+	// the text shown before and after the usage
+	Prolog string
+	Epilog string
+	// the example of the command
+	Example string
+
+	// the options, the persistent options of the parents and the arguments
+	Options       []UsageField
+	GlobalOptions []UsageField
+	Arguments     []UsageField
+	// the sub-commands grouped by the category
+	Categories []UsageCategory
+
+	// the width of the option column and the terminal, 0 when unknown
+	Column int
+	Width  int
+	// the style of the usage, empty when the color is disabled
+	Style Style
+}
+
+// the sub-commands in the same category
+type UsageCategory struct {
+	// the name of the category, empty for the uncategorized sub-commands
+	Name string
+	// the sub-commands in the category
+	Commands []UsageField
+}
+
+// the field shown in the usage
+type UsageField struct {
+	Name     string
+	Shortcut string
+	Aliases  []string
+	Hint     string
+	Desc     string
+	Default  string
+	Choices  []string
+
+	Required   bool
+	Deprecated bool
+
+	// the rendered option column and the description column
+	Option      string
+	Description string
+}
+
+// the template functions used in the usage template
+func UsageFuncs() (funcs template.FuncMap) {
+	funcs = template.FuncMap{
+		// render the field with the hanging indent description
+		"row": func(data UsageData, field UsageField) string {
+			return strings.Join(renderRow(field, data.Column, data.Width, data.Style), "\n")
+		},
+		// render the section heading
+		"heading": func(data UsageData, text string) string {
+			return data.Style.Apply(data.Style.Heading, text)
+		},
+		// indent each line of the text
+		"indent": func(n int, text string) string {
+			lines := strings.Split(text, "\n")
+			for idx, line := range lines {
+				if line != "" {
+					lines[idx] = strings.Repeat(" ", n) + line
+				}
+			}
+			return strings.Join(lines, "\n")
+		},
+	}
+	return
+}
+
+var (
+	// the parsed default usage template
+	default_template = template.Must(template.New("usage").Funcs(UsageFuncs()).Parse(USAGE_TEMPLATE))
+)
+
+// write the usage to the pass io.Writer
+func (stropt *StrOpt) Usage(w io.Writer) {
+	buff := &bytes.Buffer{}
+
+	data := stropt.UsageData(stropt.terminalWidth(w))
+	data.Style = stropt.getStyle(w)
+	if err := stropt.getTemplate().Execute(buff, data); err != nil {
+		// fallback to the default template
+		stropt.Warnf("cannot render usage: %v", err)
+
+		buff.Reset()
+		default_template.Execute(buff, data) //nolint
+	}
+
+	w.Write(buff.Bytes()) // nolint
+}
+
+// override the usage template, the template is executed with UsageData and
+// may use the functions in UsageFuncs.
+func (stropt *StrOpt) Template(tmpl *template.Template) {
+	stropt.template = tmpl
+}
+
+// set the text shown before the usage, inherited by sub-commands
+func (stropt *StrOpt) Prolog(text string) {
+	stropt.prolog = text
+}
+
+// set the text shown after the usage, inherited by sub-commands
+func (stropt *StrOpt) Epilog(text string) {
+	stropt.epilog = text
+}
+
+// set the example of the command, override the example tag
+func (stropt *StrOpt) Example(text string) {
+	stropt.example = text
+}
+
+// the usage template, inherit from the parent
+func (stropt *StrOpt) getTemplate() (tmpl *template.Template) {
+	switch {
+	case stropt.template != nil:
+		tmpl = stropt.template
+	case stropt.parent != nil:
+		tmpl = stropt.parent.getTemplate()
+	default:
+		tmpl = default_template
+	}
+	return
+}
+
+// build the data model of the usage with the terminal width
+func (stropt *StrOpt) UsageData(width int) (data UsageData) {
+	data = UsageData{
+		Name:    stropt.name,
+		Path:    stropt.usagePrefix() + stropt.name,
+		Example: stropt.example,
+		Width:   width,
+	}
+
+	for parser := stropt; parser != nil; parser = parser.parent {
+		// the prolog and epilog inherit from the parent
+		if data.Prolog == "" {
+			data.Prolog = parser.prolog
+		}
+		if data.Epilog == "" {
+			data.Epilog = parser.epilog
+		}
+	}
+
+	if example, ok := stropt.tag.Lookup(KEY_EXAMPLE); ok && data.Example == "" {
+		data.Example = example
+	}
+
+	// only show the visible fields
+	data.Options = stropt.usageFields(stropt.visible(stropt.fields), false)
+	data.GlobalOptions = stropt.usageFields(stropt.visible(stropt.persistent()), false)
+	data.Arguments = stropt.usageFields(stropt.visible(stropt.args_fields), true)
+
+	categories, groups := categorize(stropt.visible(stropt.subs()))
+	for _, category := range categories {
+		data.Categories = append(data.Categories, UsageCategory{
+			Name:     category,
+			Commands: stropt.usageFields(groups[category], true),
+		})
+	}
+
+	// the column width computed from the actual fields
+	data.Column = usage_min_column
+	for _, fields := range [][]UsageField{data.Options, data.GlobalOptions, data.Arguments} {
+		data.Column = usageColumn(data.Column, fields)
+	}
+	for _, category := range data.Categories {
+		data.Column = usageColumn(data.Column, category.Commands)
+	}
+
+	if data.Column > usage_max_column {
+		// too long and the description shown in the next line
+		data.Column = usage_max_column
+	}
+
+	return
+}
+
+// convert the fields to the usage fields
+func (stropt *StrOpt) usageFields(fields []Field, sub bool) (usage_fields []UsageField) {
+	for _, field := range fields {
+		usage_field := UsageField{
+			Name:     field.GetName(),
+			Shortcut: field.GetShortcut(),
+			Aliases:  aliases(field),
+			Hint:     field.Hint(),
+			Default:  stropt.defaultValue(field),
+			Choices:  field.GetChoice(),
+			Required: stropt.field_set_attr(field, KEY_ATTR_REQUIRED),
+		}
+
+		usage_field.Desc, _ = field.GetTag().Lookup(KEY_DESC)
+		_, usage_field.Deprecated = field.GetTag().Lookup(KEY_DEPRECATED)
+		usage_field.Option, usage_field.Description = stropt.description(field, sub)
+
+		usage_fields = append(usage_fields, usage_field)
+	}
+	return
+}
+
+// the maximal width of the option column
+func usageColumn(column int, fields []UsageField) int {
+	for _, field := range fields {
+		if size := utf8.RuneCountInString(field.Option); size > column {
+			column = size
+		}
+	}
+	return column
+}
+
+// render the single row with the hanging indent description, the layout is
+// computed by the plain text and then styled.
+func renderRow(field UsageField, column, width int, style Style) (lines []string) {
+	left := strings.TrimRight(field.Option, " ")
+	indent := usage_indent + column + 1
+
+	var descs []string
+	for _, paragraph := range strings.Split(field.Description, "\n") {
+		// wrap each line of the multi-line description
+		for _, desc := range wrap(paragraph, width-indent) {
+			descs = append(descs, strings.TrimRight(desc, " "))
+		}
+	}
+
+	if field.Required {
+		styleRequired(style, descs)
+	}
+
+	styled := styleOption(style, left, field.Hint)
+	switch size := utf8.RuneCountInString(left); {
+	case size > column:
+		lines = append(lines, strings.Repeat(" ", usage_indent)+styled)
+	default:
+		line := fmt.Sprintf("%v%v%v %v", strings.Repeat(" ", usage_indent), styled, strings.Repeat(" ", column-size), descs[0])
+		lines = append(lines, strings.TrimRight(line, " "))
+		descs = descs[1:]
+	}
+
+	for _, desc := range descs {
+		line := strings.Repeat(" ", indent) + desc
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return
+}
+
+// wrap the text by words, the text is kept as-is when fit the width or the
+// width is unknown.
+func wrap(text string, width int) (lines []string) {
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		lines = append(lines, text)
+		return
+	}
+
+	if width < usage_min_wrap {
+		// too narrow, may overflow the terminal
+		width = usage_min_wrap
+	}
+
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line = line + " " + word
+		}
+	}
+
+	lines = append(lines, line)
+	return
+}
+
+// override the terminal width used to wrap the usage, disable wrap when
+// the width is negative
+func (stropt *StrOpt) Width(width int) {
+	stropt.width = width
+}
+
+// the terminal width of the usage, detect by the override width, COLUMNS and
+// the terminal size of the writer. Return 0 when unknown or the writer is not
+// the terminal, so the usage written to the pipe or file never wraps.
+func (stropt *StrOpt) terminalWidth(w io.Writer) (width int) {
+	for parser := stropt; parser != nil; parser = parser.parent {
+		if parser.width != 0 {
+			// override by the parser
+			width = parser.width
+			return
+		}
+	}
+
+	file, ok := w.(*os.File)
+	if !ok || !isTerminal(file.Fd()) {
+		// not the terminal
+		return
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		width = columns
+		return
+	}
+
+	width = ttyWidth(file.Fd())
+	return
+}
@@ -0,0 +1,162 @@
+package stropt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+type Wrapped struct {
+	Output          string `shortcut:"o" desc:"write the generated output into the file instead of the standard output"`
+	VeryLongOptionX string `name:"very-long-option-name" desc:"the long option"`
+	Note            string `desc:"the first paragraph\n\nthe second paragraph"`
+}
+
+func TestWrappedUsage(t *testing.T) {
+	parser := MustNew(&Wrapped{})
+	parser.Width(60)
+
+	buff := &bytes.Buffer{}
+	parser.Usage(buff)
+
+	expect := strings.Join([]string{
+		"usage: wrapped [OPTION]",
+		"",
+		"options:",
+		"     -o --output STR                write the generated",
+		"                                    output into the file",
+		"                                    instead of the standard",
+		"                                    output",
+		"        --very-long-option-name STR the long option",
+		"        --note STR                  the first paragraph",
+		"",
+		"                                    the second paragraph",
+		"",
+	}, "\n")
+	if buff.String() != expect {
+		t.Errorf("expect usage:\n%v\nbut got:\n%v", expect, buff.String())
+	}
+
+	// the COLUMNS only applies on the terminal
+	setenv(t, "COLUMNS", "30")
+	parser.Width(0)
+	buff.Reset()
+	parser.Usage(buff)
+	if strings.Contains(buff.String(), "\n                                    output into the file") {
+		t.Errorf("expect not wrap on the non-terminal writer:\n%v", buff.String())
+	} else if width := parser.terminalWidth(buff); width != 0 {
+		t.Errorf("expect unknown width on the non-terminal writer: %v", width)
+	}
+}
+
+type Branded struct {
+	Verbose bool `shortcut:"v" desc:"verbose mode"`
+
+	Deploy *struct {
+		Force bool `desc:"force deploy"`
+	} `desc:"deploy the app" example:"app deploy --force\napp deploy"`
+}
+
+func TestTemplateUsage(t *testing.T) {
+	parser := MustNew(&Branded{})
+	parser.Name("app")
+	parser.Prolog("app - the branded application")
+	parser.Epilog("see https://example.com for more details")
+
+	buff := &bytes.Buffer{}
+	parser.sub_fields["deploy"].(*StrOpt).Usage(buff)
+
+	expect := strings.Join([]string{
+		"app - the branded application",
+		"",
+		"usage: app [GLOBAL OPTIONS] deploy [OPTION]",
+		"",
+		"options:",
+		"        --force            force deploy",
+		"",
+		"examples:",
+		"    app deploy --force",
+		"    app deploy",
+		"",
+		"see https://example.com for more details",
+		"",
+	}, "\n")
+	if buff.String() != expect {
+		t.Errorf("expect usage:\n%v\nbut got:\n%v", expect, buff.String())
+	}
+
+	tmpl := template.Must(template.New("usage").Funcs(UsageFuncs()).Parse(
+		"{{ .Path }}:{{ range .Options }} -{{ .Shortcut }}/{{ .Name }}{{ end }}{{ range .Categories }}{{ range .Commands }} {{ .Name }}{{ end }}{{ end }}",
+	))
+	parser.Template(tmpl)
+
+	buff.Reset()
+	parser.Usage(buff)
+	if buff.String() != "app: -v/verbose deploy" {
+		t.Errorf("expect customized usage: %v", buff.String())
+	}
+}
+
+// set the environment variable and restore after the test, like t.Setenv
+// which is not available before Go 1.17
+func setenv(t *testing.T, key, value string) {
+	prev, ok := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev) //nolint
+			return
+		}
+		os.Unsetenv(key) //nolint
+	})
+
+	os.Setenv(key, value) //nolint
+}
+
+func TestColorUsage(t *testing.T) {
+	parser := MustNew(&Branded{})
+	parser.Name("app")
+
+	buff := &bytes.Buffer{}
+	setenv(t, "NO_COLOR", "")
+	setenv(t, "FORCE_COLOR", "")
+	parser.Usage(buff)
+	if strings.Contains(buff.String(), "\x1b[") {
+		t.Errorf("expect no color on non-terminal writer: %q", buff.String())
+	}
+
+	setenv(t, "FORCE_COLOR", "1")
+	parser.Theme(Style{Heading: "1", Option: "36", Error: "31"})
+	buff.Reset()
+	parser.ErrorAndUsage(fmt.Errorf("oops"), buff)
+	switch text := buff.String(); {
+	case !strings.HasPrefix(text, "\x1b[31merror:\x1b[0m oops\n"):
+		t.Errorf("expect colorized error prefix: %q", text)
+	case !strings.Contains(text, "\x1b[1moptions:\x1b[0m"):
+		t.Errorf("expect colorized heading: %q", text)
+	case !strings.Contains(text, "\x1b[36m-v\x1b[0m \x1b[36m--verbose\x1b[0m"):
+		t.Errorf("expect colorized option: %q", text)
+	}
+
+	marked := MustNew(&struct {
+		Name string `attr:"required" desc:"the name (required) by the server"`
+		Note string `desc:"the optional note (required)"`
+	}{})
+	buff.Reset()
+	marked.Usage(buff)
+	switch text := buff.String(); {
+	case strings.Count(text, "\x1b[31m(required)\x1b[0m") != 1:
+		t.Errorf("expect colorize the required marker once: %q", text)
+	case !strings.Contains(text, "the name (required) by the server \x1b[31m(required)\x1b[0m"):
+		t.Errorf("expect colorize the appended marker only: %q", text)
+	}
+
+	setenv(t, "NO_COLOR", "1")
+	buff.Reset()
+	parser.Usage(buff)
+	if strings.Contains(buff.String(), "\x1b[") {
+		t.Errorf("expect NO_COLOR disable color: %q", buff.String())
+	}
+}